@@ -0,0 +1,89 @@
+package pixman
+
+import (
+	"fmt"
+	"image"
+	"math"
+)
+
+// gaussianKernel returns a normalised one dimensional gaussian kernel, extending three standard deviations either side of the centre
+func gaussianKernel(sigma float64) []float64 {
+	radius := int(math.Ceil(sigma * 3))
+	kernel := make([]float64, 2*radius+1)
+	sum := 0.0
+	for i := range kernel {
+		x := float64(i - radius)
+		kernel[i] = math.Exp(-(x * x) / (2 * sigma * sigma))
+		sum += kernel[i]
+	}
+	for i := range kernel {
+		kernel[i] /= sum
+	}
+	return kernel
+}
+
+// convolve replaces the contents of this image with the result of sampling it through kernel
+func (i *Image) convolve(kernel [][]float64) error {
+	tmp, err := i.clone()
+	if err != nil {
+		return err
+	}
+	if err := tmp.SetConvolution(kernel); err != nil {
+		return err
+	}
+	i.CompositeOp(PIXMAN_OP_SRC, tmp, nil, image.Point{}, image.Point{}, image.Point{}, i.Bounds().Size())
+	return nil
+}
+
+// convolveSeparable applies a one dimensional kernel horizontally and then vertically
+func (i *Image) convolveSeparable(kernel []float64) error {
+	column := make([][]float64, len(kernel))
+	for y, weight := range kernel {
+		column[y] = []float64{weight}
+	}
+	if err := i.convolve([][]float64{kernel}); err != nil {
+		return err
+	}
+	return i.convolve(column)
+}
+
+// GaussianBlur blurs the image in place with a gaussian of standard deviation sigma pixels.
+// Pixels outside the image are treated as transparent.
+func (i *Image) GaussianBlur(sigma float64) error {
+	if sigma <= 0 {
+		return fmt.Errorf("invalid gaussian blur sigma %f", sigma)
+	}
+	return i.convolveSeparable(gaussianKernel(sigma))
+}
+
+// BoxBlur blurs the image in place, replacing each pixel with the average of the (2*radius+1)^2 pixels around it.
+// Pixels outside the image are treated as transparent.
+func (i *Image) BoxBlur(radius int) error {
+	if radius <= 0 {
+		return fmt.Errorf("invalid box blur radius %d", radius)
+	}
+	kernel := make([]float64, 2*radius+1)
+	for x := range kernel {
+		kernel[x] = 1 / float64(len(kernel))
+	}
+	return i.convolveSeparable(kernel)
+}
+
+// UnsharpMask sharpens the image in place by adding amount times the difference between
+// the image and a gaussian blur of standard deviation sigma.
+func (i *Image) UnsharpMask(sigma, amount float64) error {
+	if sigma <= 0 {
+		return fmt.Errorf("invalid unsharp mask sigma %f", sigma)
+	}
+	gaussian := gaussianKernel(sigma)
+	kernel := make([][]float64, len(gaussian))
+	for y := range kernel {
+		kernel[y] = make([]float64, len(gaussian))
+		for x := range kernel[y] {
+			kernel[y][x] = -amount * gaussian[x] * gaussian[y]
+		}
+	}
+	centre := len(gaussian) / 2
+	kernel[centre][centre] += 1 + amount
+	return i.convolve(kernel)
+}
@@ -0,0 +1,168 @@
+package pixman
+
+import (
+	"encoding/binary"
+	"image/color"
+)
+
+// channel is a colour channel's position within a pixel value
+type channel struct {
+	shift uint
+	bits  uint
+}
+
+// pixelCodec converts between colours and the pixels of a format. Pixels are read as native-endian
+// values of the format's bpp, which is how pixman itself accesses them.
+type pixelCodec struct {
+	bytes      int
+	a, r, g, b channel
+	padding    uint32      // Bits of the pixel value not used by any channel
+	model      color.Model // The colour model At returns pixels in
+}
+
+// The order of the channels in each supported format type, from the most significant bits down
+var channelOrders = map[PixmanFormatType]string{
+	PIXMAN_TYPE_A:    "a",
+	PIXMAN_TYPE_ARGB: "argb",
+	PIXMAN_TYPE_ABGR: "abgr",
+	PIXMAN_TYPE_BGRA: "bgra",
+	PIXMAN_TYPE_RGBA: "rgba",
+}
+
+// codecFormats are the formats whose pixels At and Set can read and write
+var codecFormats = []PixmanFormatCode{
+	PIXMAN_a8r8g8b8, PIXMAN_x8r8g8b8, PIXMAN_a8b8g8r8, PIXMAN_x8b8g8r8, PIXMAN_b8g8r8a8, PIXMAN_b8g8r8x8,
+	PIXMAN_r8g8b8a8, PIXMAN_r8g8b8x8, PIXMAN_x14r6g6b6, PIXMAN_x2r10g10b10, PIXMAN_a2r10g10b10,
+	PIXMAN_x2b10g10r10, PIXMAN_a2b10g10r10, PIXMAN_r8g8b8, PIXMAN_b8g8r8,
+	PIXMAN_r5g6b5, PIXMAN_b5g6r5, PIXMAN_a1r5g5b5, PIXMAN_x1r5g5b5, PIXMAN_a1b5g5r5, PIXMAN_x1b5g5r5,
+	PIXMAN_a4r4g4b4, PIXMAN_x4r4g4b4, PIXMAN_a4b4g4r4, PIXMAN_x4b4g4r4,
+	PIXMAN_a8, PIXMAN_r3g3b2, PIXMAN_b2g3r3, PIXMAN_a2r2g2b2, PIXMAN_a2b2g2r2, PIXMAN_x4a4,
+}
+
+// pixelCodecs holds the codec and colour model for each of codecFormats, derived once so that pixel access
+// is a table lookup
+var pixelCodecs = func() map[PixmanFormatCode]pixelCodec {
+	retval := make(map[PixmanFormatCode]pixelCodec, len(codecFormats))
+	for _, f := range codecFormats {
+		codec, ok := newPixelCodec(f)
+		if !ok {
+			panic("no pixel codec for " + f.String())
+		}
+		codec.model = formatModel(f, codec)
+		retval[f] = codec
+	}
+	return retval
+}()
+
+// newPixelCodec derives the codec for f from its channel layout.
+// It returns false for formats it can't handle, such as palettes, YUV, sRGB and float formats.
+func newPixelCodec(f PixmanFormatCode) (pixelCodec, bool) {
+	order, ok := channelOrders[f.Type()]
+	bpp := f.BPP()
+	if !ok || bpp%8 != 0 || bpp < 8 || bpp > 32 {
+		return pixelCodec{}, false
+	}
+	codec := pixelCodec{bytes: bpp / 8}
+	channels := map[byte]*channel{'a': &codec.a, 'r': &codec.r, 'g': &codec.g, 'b': &codec.b}
+	sizes := map[byte]int{'a': f.A(), 'r': f.R(), 'g': f.G(), 'b': f.B()}
+	for _, size := range sizes {
+		if size > 16 {
+			return pixelCodec{}, false
+		}
+	}
+
+	// ARGB and ABGR formats pack their channels into the low bits, while BGRA and RGBA pack them into the high bits
+	shift := 0
+	if t := f.Type(); t == PIXMAN_TYPE_BGRA || t == PIXMAN_TYPE_RGBA {
+		shift = bpp
+	} else {
+		shift = f.Depth()
+	}
+	used := uint32(0)
+	for i := range len(order) {
+		c := channels[order[i]]
+		c.bits = uint(sizes[order[i]])
+		shift -= int(c.bits)
+		c.shift = uint(shift)
+		used |= (1<<c.bits - 1) << c.shift
+	}
+	codec.padding = ^used & uint32(1<<bpp-1)
+	return codec, true
+}
+
+// expand scales the value held in c to 16 bits, replicating its bits as pixman does
+func (c channel) expand(pixel uint32) uint32 {
+	if c.bits == 0 {
+		return 0
+	}
+	v := (pixel >> c.shift) & (1<<c.bits - 1)
+	v <<= 16 - c.bits
+	for s := c.bits; s < 16; s *= 2 {
+		v |= v >> s
+	}
+	return v & 0xffff
+}
+
+// quantize truncates a 16 bit value to the size of c and places it in a pixel value
+func (c channel) quantize(v uint32) uint32 {
+	if c.bits == 0 {
+		return 0
+	}
+	return (v >> (16 - c.bits)) << c.shift
+}
+
+func (p pixelCodec) load(data []byte) uint32 {
+	switch p.bytes {
+	case 1:
+		return uint32(data[0])
+	case 2:
+		return uint32(binary.NativeEndian.Uint16(data))
+	case 3:
+		if binary.NativeEndian.Uint16([]byte{1, 0}) == 1 {
+			return uint32(data[0]) | uint32(data[1])<<8 | uint32(data[2])<<16
+		}
+		return uint32(data[2]) | uint32(data[1])<<8 | uint32(data[0])<<16
+	default:
+		return binary.NativeEndian.Uint32(data)
+	}
+}
+
+func (p pixelCodec) store(data []byte, pixel uint32) {
+	switch p.bytes {
+	case 1:
+		data[0] = uint8(pixel)
+	case 2:
+		binary.NativeEndian.PutUint16(data, uint16(pixel))
+	case 3:
+		if binary.NativeEndian.Uint16([]byte{1, 0}) == 1 {
+			data[0], data[1], data[2] = uint8(pixel), uint8(pixel>>8), uint8(pixel>>16)
+		} else {
+			data[2], data[1], data[0] = uint8(pixel), uint8(pixel>>8), uint8(pixel>>16)
+		}
+	default:
+		binary.NativeEndian.PutUint32(data, pixel)
+	}
+}
+
+// rgba expands a pixel into premultiplied 16 bit channels, like color.Color.RGBA. Formats without alpha are opaque.
+func (p pixelCodec) rgba(pixel uint32) (r, g, b, a uint32) {
+	a = 0xffff
+	if p.a.bits > 0 {
+		a = p.a.expand(pixel)
+	}
+	return p.r.expand(pixel), p.g.expand(pixel), p.b.expand(pixel), a
+}
+
+// decode converts a pixel into a premultiplied colour. Channels are expanded as stored, so a colour channel may
+// exceed alpha where truncation left it larger, just as pixman reads it back.
+func (p pixelCodec) decode(pixel uint32) color.RGBA64 {
+	r, g, b, a := p.rgba(pixel)
+	return color.RGBA64{R: uint16(r), G: uint16(g), B: uint16(b), A: uint16(a)}
+}
+
+// encode converts a colour into a pixel, truncating each channel to the format's precision independently, as
+// pixman does. Formats without alpha store the premultiplied colour, and any padding bits are set.
+func (p pixelCodec) encode(c color.Color) uint32 {
+	r, g, b, a := c.RGBA()
+	return p.a.quantize(a) | p.r.quantize(r) | p.g.quantize(g) | p.b.quantize(b) | p.padding
+}
@@ -0,0 +1,93 @@
+package pixman
+
+import "image/color"
+
+// Colour types matching the pixel layout of common pixman formats. Each holds a premultiplied pixel value
+// in the format's channel order, and expands it to 16 bits per channel by bit replication as pixman does.
+type (
+	// RGB565 is an opaque colour with 5 bits of red, 6 of green and 5 of blue, as stored by PIXMAN_r5g6b5
+	RGB565 uint16
+	// ARGB1555 is a colour with 1 bit of alpha and 5 bits of each colour, as stored by PIXMAN_a1r5g5b5
+	ARGB1555 uint16
+	// ARGB4444 is a colour with 4 bits of alpha and of each colour, as stored by PIXMAN_a4r4g4b4
+	ARGB4444 uint16
+	// A8 is an 8 bit alpha value, as stored by PIXMAN_a8
+	A8 uint8
+	// ARGB2101010 is a colour with 2 bits of alpha and 10 bits of each colour, as stored by PIXMAN_a2r10g10b10
+	ARGB2101010 uint32
+)
+
+var (
+	rgb565Codec, _      = newPixelCodec(PIXMAN_r5g6b5)
+	argb1555Codec, _    = newPixelCodec(PIXMAN_a1r5g5b5)
+	argb4444Codec, _    = newPixelCodec(PIXMAN_a4r4g4b4)
+	a8Codec, _          = newPixelCodec(PIXMAN_a8)
+	argb2101010Codec, _ = newPixelCodec(PIXMAN_a2r10g10b10)
+)
+
+func (c RGB565) RGBA() (r, g, b, a uint32)      { return rgb565Codec.rgba(uint32(c)) }
+func (c ARGB1555) RGBA() (r, g, b, a uint32)    { return argb1555Codec.rgba(uint32(c)) }
+func (c ARGB4444) RGBA() (r, g, b, a uint32)    { return argb4444Codec.rgba(uint32(c)) }
+func (c A8) RGBA() (r, g, b, a uint32)          { return a8Codec.rgba(uint32(c)) }
+func (c ARGB2101010) RGBA() (r, g, b, a uint32) { return argb2101010Codec.rgba(uint32(c)) }
+
+// Models converting colours to the types above. Each channel of the premultiplied colour is truncated to the
+// type's precision independently, matching how pixman stores colours, so a colour channel can end up greater
+// than alpha when alpha loses more precision. RGB565Model drops alpha, leaving the premultiplied colour.
+var (
+	RGB565Model = color.ModelFunc(func(c color.Color) color.Color {
+		if v, ok := c.(RGB565); ok {
+			return v
+		}
+		return RGB565(rgb565Codec.encode(c))
+	})
+	ARGB1555Model = color.ModelFunc(func(c color.Color) color.Color {
+		if v, ok := c.(ARGB1555); ok {
+			return v
+		}
+		return ARGB1555(argb1555Codec.encode(c))
+	})
+	ARGB4444Model = color.ModelFunc(func(c color.Color) color.Color {
+		if v, ok := c.(ARGB4444); ok {
+			return v
+		}
+		return ARGB4444(argb4444Codec.encode(c))
+	})
+	A8Model = color.ModelFunc(func(c color.Color) color.Color {
+		if v, ok := c.(A8); ok {
+			return v
+		}
+		return A8(a8Codec.encode(c))
+	})
+	ARGB2101010Model = color.ModelFunc(func(c color.Color) color.Color {
+		if v, ok := c.(ARGB2101010); ok {
+			return v
+		}
+		return ARGB2101010(argb2101010Codec.encode(c))
+	})
+)
+
+// formatModel returns the colour model matching the precision of f, whose pixels codec converts. Formats sharing
+// a precision but not a channel order, such as PIXMAN_r5g6b5 and PIXMAN_b5g6r5, share a model. Other formats,
+// including those with padding in place of alpha, get a model that quantizes through codec and returns
+// color.RGBA64, so that formats without alpha read as opaque.
+func formatModel(f PixmanFormatCode, codec pixelCodec) color.Model {
+	type precision struct{ a, r, g, b int }
+	switch (precision{f.A(), f.R(), f.G(), f.B()}) {
+	case precision{0, 5, 6, 5}:
+		return RGB565Model
+	case precision{1, 5, 5, 5}:
+		return ARGB1555Model
+	case precision{4, 4, 4, 4}:
+		return ARGB4444Model
+	case precision{8, 0, 0, 0}:
+		return A8Model
+	case precision{2, 10, 10, 10}:
+		return ARGB2101010Model
+	case precision{8, 8, 8, 8}:
+		return color.RGBAModel
+	}
+	return color.ModelFunc(func(c color.Color) color.Color {
+		return codec.decode(codec.encode(c))
+	})
+}
@@ -0,0 +1,197 @@
+package pixman
+
+import (
+	"fmt"
+	"math"
+)
+
+// This is a port of pixman_filter_create_separable_convolution from pixman-filter.c. Building the
+// parameters in Go keeps them in Go memory, as the C version returns a buffer that must be released
+// with free from whichever C library pixman was linked against.
+
+// separableKernel describes one of pixman's filter kernels, and the width over which it is non-zero
+type separableKernel struct {
+	fn    func(x float64) float64
+	width float64
+}
+
+var separableKernels = [...]separableKernel{
+	PIXMAN_KERNEL_IMPULSE:            {impulseKernel, 0},
+	PIXMAN_KERNEL_BOX:                {boxKernel, 1},
+	PIXMAN_KERNEL_LINEAR:             {linearKernel, 2},
+	PIXMAN_KERNEL_CUBIC:              {cubicKernel, 4},
+	PIXMAN_KERNEL_GAUSSIAN:           {gaussianFilterKernel, 5},
+	PIXMAN_KERNEL_LANCZOS2:           {lanczos2Kernel, 4},
+	PIXMAN_KERNEL_LANCZOS3:           {lanczos3Kernel, 6},
+	PIXMAN_KERNEL_LANCZOS3_STRETCHED: {lanczos3StretchedKernel, 8},
+}
+
+func impulseKernel(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return 0
+}
+
+func boxKernel(x float64) float64 {
+	return 1
+}
+
+func linearKernel(x float64) float64 {
+	return 1 - math.Abs(x)
+}
+
+func gaussianFilterKernel(x float64) float64 {
+	const sigma = math.Sqrt2 / 2
+	return math.Exp(-x*x/(2*sigma*sigma)) / (sigma * math.Sqrt(2*math.Pi))
+}
+
+func sinc(x float64) float64 {
+	if x == 0 {
+		return 1
+	}
+	return math.Sin(math.Pi*x) / (math.Pi * x)
+}
+
+func lanczos2Kernel(x float64) float64 {
+	return sinc(x) * sinc(x/2)
+}
+
+func lanczos3Kernel(x float64) float64 {
+	return sinc(x) * sinc(x/3)
+}
+
+func lanczos3StretchedKernel(x float64) float64 {
+	return lanczos3Kernel(x * 0.75)
+}
+
+// cubicKernel is the Mitchell-Netravali filter, with B = C = 1/3
+func cubicKernel(x float64) float64 {
+	const b, c = 1.0 / 3, 1.0 / 3
+	ax := math.Abs(x)
+	switch {
+	case ax < 1:
+		return (((12-9*b-6*c)*ax+(-18+12*b+6*c))*ax*ax + (6 - 2*b)) / 6
+	case ax < 2:
+		return ((((-b-6*c)*ax+(6*b+30*c))*ax+(-12*b-48*c))*ax + (8*b + 24*c)) / 6
+	default:
+		return 0
+	}
+}
+
+// kernelIntegral scales kernel2 by scale, aligns x1 in kernel1 with x2 in kernel2, and integrates the
+// product of the two kernels across width
+func kernelIntegral(kernel1 PixmanKernel, x1 float64, kernel2 PixmanKernel, scale, x2, width float64) float64 {
+	switch {
+	case kernel1 == PIXMAN_KERNEL_BOX && kernel2 == PIXMAN_KERNEL_BOX:
+		return width
+	// The linear kernel isn't differentiable at 0, so split intervals that cross it
+	case kernel1 == PIXMAN_KERNEL_LINEAR && x1 < 0 && x1+width > 0:
+		return kernelIntegral(kernel1, x1, kernel2, scale, x2, -x1) +
+			kernelIntegral(kernel1, 0, kernel2, scale, x2-x1, width+x1)
+	case kernel2 == PIXMAN_KERNEL_LINEAR && x2 < 0 && x2+width > 0:
+		return kernelIntegral(kernel1, x1, kernel2, scale, x2, -x2) +
+			kernelIntegral(kernel1, x1-x2, kernel2, scale, 0, width+x2)
+	case kernel1 == PIXMAN_KERNEL_IMPULSE:
+		return separableKernels[kernel2].fn(x2 * scale)
+	case kernel2 == PIXMAN_KERNEL_IMPULSE:
+		return separableKernels[kernel1].fn(x1)
+	}
+
+	// Simpson's rule over 12 segments, which pixman found accurate enough for lanczos3 with linear
+	const segments = 12
+	sample := func(a1, a2 float64) float64 {
+		return separableKernels[kernel1].fn(a1) * separableKernels[kernel2].fn(a2*scale)
+	}
+	h := width / segments
+	s := sample(x1, x2)
+	for i := 1; i < segments; i += 2 {
+		s += 4 * sample(x1+h*float64(i), x2+h*float64(i))
+	}
+	for i := 2; i < segments; i += 2 {
+		s += 2 * sample(x1+h*float64(i), x2+h*float64(i))
+	}
+	s += sample(x1+width, x2+width)
+	return h * s / 3
+}
+
+// separableFilterWidth returns the number of taps needed to cover both kernels at the given scale
+func separableFilterWidth(reconstruct, sample PixmanKernel, scale float64) int {
+	return max(1, int(math.Ceil(separableKernels[reconstruct].width+scale*separableKernels[sample].width)))
+}
+
+// appendSeparableFilter appends width taps for each of nPhases subpixel phases to params, each
+// phase normalised to sum to exactly one
+func appendSeparableFilter(params []PixmanFixed, width int, reconstruct, sample PixmanKernel, scale float64, nPhases int) []PixmanFixed {
+	step := 1 / float64(nPhases)
+	rlow := -separableKernels[reconstruct].width / 2
+	rhigh := rlow + separableKernels[reconstruct].width
+	for i := range nPhases {
+		frac := step/2 + float64(i)*step
+		x1 := int(math.Ceil(frac - float64(width)/2 - 0.5))
+		phase := make([]PixmanFixed, width)
+		total := 0.0
+		for x := range phase {
+			pos := float64(x1+x) + 0.5 - frac
+			slow := pos - scale*separableKernels[sample].width/2
+			shigh := slow + scale*separableKernels[sample].width
+			c := 0.0
+			if rhigh >= slow && rlow <= shigh {
+				ilow := max(slow, rlow)
+				ihigh := min(shigh, rhigh)
+				c = kernelIntegral(reconstruct, ilow, sample, 1/scale, ilow-pos, ihigh-ilow)
+			}
+			phase[x] = PixmanFixed(math.Floor(c*65536 + 0.5))
+			total += float64(phase[x])
+		}
+
+		// Normalise with error diffusion, then put the last fraction of error on the first tap,
+		// the only one that hasn't had any diffused into it
+		newTotal := PixmanFixed(0)
+		if total != 0 {
+			scaleTotal := 65536 / total
+			e := 0.0
+			for x := range phase {
+				v := float64(phase[x])*scaleTotal + e
+				t := PixmanFixed(math.Floor(v + 0.5))
+				e = v - float64(t)
+				newTotal += t
+				phase[x] = t
+			}
+		}
+		phase[0] += FixedFromInt(1) - newTotal
+		params = append(params, phase...)
+	}
+	return params
+}
+
+// separableConvolution returns the PIXMAN_FILTER_SEPARABLE_CONVOLUTION parameters for the given
+// scales, kernels and subsample bits, as pixman_filter_create_separable_convolution does
+func separableConvolution(scaleX, scaleY PixmanFixed, reconstructX, reconstructY, sampleX, sampleY PixmanKernel, subsampleBitsX, subsampleBitsY int) ([]PixmanFixed, error) {
+	for _, kernel := range []PixmanKernel{reconstructX, reconstructY, sampleX, sampleY} {
+		if int(kernel) >= len(separableKernels) {
+			return nil, fmt.Errorf("unknown filter kernel %s", kernel)
+		}
+	}
+	// Each subsample bit doubles the size of the parameters, and pixman keeps the count in an int
+	for _, bits := range []int{subsampleBitsX, subsampleBitsY} {
+		if bits < 0 || bits > 16 {
+			return nil, fmt.Errorf("subsample bits %d out of range 0..16", bits)
+		}
+	}
+	if scaleX == 0 || scaleY == 0 {
+		return nil, fmt.Errorf("filter scale %v x %v must not be zero", scaleX.Float(), scaleY.Float())
+	}
+	sx := math.Abs(scaleX.Float())
+	sy := math.Abs(scaleY.Float())
+	width := separableFilterWidth(reconstructX, sampleX, sx)
+	height := separableFilterWidth(reconstructY, sampleY, sy)
+	phasesX := 1 << subsampleBitsX
+	phasesY := 1 << subsampleBitsY
+
+	params := make([]PixmanFixed, 0, 4+width*phasesX+height*phasesY)
+	params = append(params, FixedFromInt(width), FixedFromInt(height), FixedFromInt(subsampleBitsX), FixedFromInt(subsampleBitsY))
+	params = appendSeparableFilter(params, width, reconstructX, sampleX, sx, phasesX)
+	params = appendSeparableFilter(params, height, reconstructY, sampleY, sy, phasesY)
+	return params, nil
+}
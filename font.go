@@ -0,0 +1,358 @@
+package pixman
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"os"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// Font is a bitmap font whose glyphs are rendered through a pixman glyph cache
+type Font struct {
+	glyphs   map[rune]*fontGlyph
+	fallback rune // Drawn in place of runes the font lacks, if the font has it
+	ascent   int
+	descent  int
+	cache    *GlyphCache
+}
+
+// fontGlyph is a single glyph bitmap, positioned relative to the pen on the baseline
+type fontGlyph struct {
+	width, height    int
+	originX, originY int    // The point in the bitmap placed on the pen position
+	advance          int    // Horizontal distance to the next pen position
+	bitmap           []byte // One byte of coverage per pixel, width*height long
+}
+
+// LoadFont reads a BDF or PSF (version 1 or 2) bitmap font from path
+func LoadFont(path string) (*Font, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	if bytes.HasPrefix(data, []byte("STARTFONT")) {
+		return ParseBDF(bytes.NewReader(data))
+	}
+	return ParsePSF(bytes.NewReader(data))
+}
+
+// newFont wraps parsed glyphs, picking a fallback glyph for missing runes
+func newFont(glyphs map[rune]*fontGlyph, ascent, descent int, fallback rune) (*Font, error) {
+	if len(glyphs) == 0 {
+		return nil, fmt.Errorf("font has no glyphs")
+	}
+	cache, err := NewGlyphCache()
+	if err != nil {
+		return nil, err
+	}
+	if _, ok := glyphs[fallback]; !ok {
+		fallback = '?'
+	}
+	return &Font{glyphs: glyphs, fallback: fallback, ascent: ascent, descent: descent, cache: cache}, nil
+}
+
+// Ascent returns the distance in pixels from the top of a line to its baseline
+func (f *Font) Ascent() int {
+	return f.ascent
+}
+
+// Descent returns the distance in pixels from the baseline to the bottom of a line
+func (f *Font) Descent() int {
+	return f.descent
+}
+
+// LineHeight returns the distance in pixels between the baselines of consecutive lines
+func (f *Font) LineHeight() int {
+	return f.ascent + f.descent
+}
+
+// glyph returns the glyph for r, or the fallback glyph if the font lacks r, along with the rune it belongs to.
+// The glyph is nil if the font lacks both.
+func (f *Font) glyph(r rune) (*fontGlyph, rune) {
+	if g, ok := f.glyphs[r]; ok {
+		return g, r
+	}
+	return f.glyphs[f.fallback], f.fallback
+}
+
+// PSF magic numbers and flags
+// See https://www.win.tue.nl/~aeb/linux/kbd/font-formats-1.html
+const (
+	psf1Magic        = 0x0436
+	psf1Mode512      = 0x01
+	psf1ModeHasTab   = 0x02
+	psf1ModeSeq      = 0x04
+	psf1Separator    = 0xffff
+	psf1StartSeq     = 0xfffe
+	psf2Magic        = 0x864ab572
+	psf2HasUnicode   = 0x01
+	psf2Separator    = 0xff
+	psf2StartSeq     = 0xfe
+	psf1HeaderLength = 4
+)
+
+// ParsePSF reads a PC Screen Font, version 1 or 2, as used by the Linux console.
+// Fonts without a unicode table map glyph n to rune n.
+func ParsePSF(r io.Reader) (*Font, error) {
+	data, err := io.ReadAll(r)
+	if err != nil {
+		return nil, err
+	}
+	if len(data) >= psf1HeaderLength && binary.LittleEndian.Uint16(data) == psf1Magic {
+		return parsePSF1(data)
+	}
+	if len(data) >= 32 && binary.LittleEndian.Uint32(data) == psf2Magic {
+		return parsePSF2(data)
+	}
+	return nil, fmt.Errorf("not a PSF font")
+}
+
+func parsePSF1(data []byte) (*Font, error) {
+	mode := data[2]
+	height := int(data[3])
+	count := 256
+	if mode&psf1Mode512 != 0 {
+		count = 512
+	}
+	end := psf1HeaderLength + count*height
+	if height == 0 || len(data) < end {
+		return nil, fmt.Errorf("truncated PSF1 font: %d bytes for %d glyphs of height %d", len(data), count, height)
+	}
+	bitmaps := make([]*fontGlyph, count)
+	for i := range bitmaps {
+		start := psf1HeaderLength + i*height
+		bitmaps[i] = psfGlyph(data[start:start+height], 8, height)
+	}
+
+	glyphs := make(map[rune]*fontGlyph)
+	if mode&(psf1ModeHasTab|psf1ModeSeq) == 0 {
+		for i, g := range bitmaps {
+			glyphs[rune(i)] = g
+		}
+	} else {
+		table := data[end:]
+		for i := 0; i < count && len(table) >= 2; i++ {
+			inSequence := false
+			for len(table) >= 2 {
+				value := binary.LittleEndian.Uint16(table)
+				table = table[2:]
+				if value == psf1Separator {
+					break
+				}
+				if value == psf1StartSeq {
+					// Sequences of combining characters aren't supported, only single code points
+					inSequence = true
+				}
+				if !inSequence {
+					glyphs[rune(value)] = bitmaps[i]
+				}
+			}
+		}
+	}
+	return newFont(glyphs, height, 0, '?')
+}
+
+func parsePSF2(data []byte) (*Font, error) {
+	header := make([]uint32, 8)
+	for i := range header {
+		header[i] = binary.LittleEndian.Uint32(data[4*i:])
+	}
+	headerSize, flags, count, charSize, height, width := int(header[2]), header[3], int(header[4]), int(header[5]), int(header[6]), int(header[7])
+	// The header is untrusted, so every size is checked against the data by division before anything is multiplied
+	if headerSize < 32 || headerSize > len(data) {
+		return nil, fmt.Errorf("invalid PSF2 header size %d", headerSize)
+	}
+	if width <= 0 || height <= 0 || charSize <= 0 || charSize > len(data)-headerSize || height > charSize/((width-1)/8+1) {
+		return nil, fmt.Errorf("invalid PSF2 glyph size %dx%d with %d bytes per glyph", width, height, charSize)
+	}
+	if count < 0 || count > (len(data)-headerSize)/charSize {
+		return nil, fmt.Errorf("truncated PSF2 font: %d bytes for %d glyphs of %d bytes", len(data), count, charSize)
+	}
+	end := headerSize + count*charSize
+	bitmaps := make([]*fontGlyph, count)
+	for i := range bitmaps {
+		start := headerSize + i*charSize
+		bitmaps[i] = psfGlyph(data[start:start+charSize], width, height)
+	}
+
+	glyphs := make(map[rune]*fontGlyph)
+	if flags&psf2HasUnicode == 0 {
+		for i, g := range bitmaps {
+			glyphs[rune(i)] = g
+		}
+	} else {
+		table := data[end:]
+		for i := 0; i < count && len(table) > 0; i++ {
+			inSequence := false
+			for len(table) > 0 {
+				if table[0] == psf2Separator {
+					table = table[1:]
+					break
+				}
+				if table[0] == psf2StartSeq {
+					inSequence = true
+					table = table[1:]
+					continue
+				}
+				r, size := utf8.DecodeRune(table)
+				table = table[size:]
+				if !inSequence {
+					glyphs[r] = bitmaps[i]
+				}
+			}
+		}
+	}
+	return newFont(glyphs, height, 0, '?')
+}
+
+// psfGlyph unpacks a PSF bitmap of rows padded to whole bytes, most significant bit first.
+// PSF glyphs are character cells, so the origin is the bottom left corner.
+func psfGlyph(bits []byte, width, height int) *fontGlyph {
+	g := &fontGlyph{width: width, height: height, originY: height, advance: width}
+	g.bitmap = unpackBits(bits, width, height)
+	return g
+}
+
+func unpackBits(bits []byte, width, height int) []byte {
+	rowBytes := (width + 7) / 8
+	bitmap := make([]byte, width*height)
+	for y := range height {
+		for x := range width {
+			if i := y*rowBytes + x/8; i < len(bits) && bits[i]&(0x80>>(x%8)) != 0 {
+				bitmap[y*width+x] = 0xff
+			}
+		}
+	}
+	return bitmap
+}
+
+// BDF glyph sizes are untrusted and the glyph bitmap is allocated from them, so larger glyphs are rejected
+const bdfMaxGlyphSize = 1024
+
+// ParseBDF reads a font in the Glyph Bitmap Distribution Format.
+// See https://adobe-type-tools.github.io/font-tech-notes/pdfs/5005.BDF_Spec.pdf
+func ParseBDF(r io.Reader) (*Font, error) {
+	scanner := bufio.NewScanner(r)
+	glyphs := make(map[rune]*fontGlyph)
+	ascent, descent := -1, -1
+	var boxHeight, boxY int
+	fallback := rune('?')
+
+	var g *fontGlyph
+	encoding := rune(-1)
+	var bitmap []byte
+	inBitmap := false
+	line := 0
+	for scanner.Scan() {
+		line++
+		fields := strings.Fields(scanner.Text())
+		if len(fields) == 0 {
+			continue
+		}
+		ints := func(n int) ([]int, error) {
+			if len(fields) < n+1 {
+				return nil, fmt.Errorf("line %d: %s needs %d values", line, fields[0], n)
+			}
+			retval := make([]int, n)
+			for i := range retval {
+				v, err := strconv.Atoi(fields[i+1])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: %w", line, err)
+				}
+				retval[i] = v
+			}
+			return retval, nil
+		}
+
+		if inBitmap {
+			if fields[0] != "ENDCHAR" {
+				// Each row is padded to whole bytes, most significant bit first
+				row, err := hex.DecodeString(fields[0])
+				if err != nil {
+					return nil, fmt.Errorf("line %d: invalid bitmap row: %w", line, err)
+				}
+				if rowBytes := (g.width + 7) / 8; len(row) < rowBytes {
+					row = append(row, make([]byte, rowBytes-len(row))...)
+				} else {
+					row = row[:rowBytes]
+				}
+				bitmap = append(bitmap, row...)
+				continue
+			}
+			inBitmap = false
+		}
+
+		var err error
+		var v []int
+		switch fields[0] {
+		case "FONTBOUNDINGBOX":
+			if v, err = ints(4); err == nil {
+				boxHeight, boxY = v[1], v[3]
+			}
+		case "FONT_ASCENT":
+			if v, err = ints(1); err == nil {
+				ascent = v[0]
+			}
+		case "FONT_DESCENT":
+			if v, err = ints(1); err == nil {
+				descent = v[0]
+			}
+		case "DEFAULT_CHAR":
+			if v, err = ints(1); err == nil {
+				fallback = rune(v[0])
+			}
+		case "STARTCHAR":
+			g = &fontGlyph{}
+			encoding = -1
+			bitmap = nil
+		case "ENCODING":
+			if v, err = ints(1); err == nil {
+				encoding = rune(v[0])
+			}
+		case "DWIDTH":
+			if g != nil {
+				if v, err = ints(1); err == nil {
+					g.advance = v[0]
+				}
+			}
+		case "BBX":
+			if g != nil {
+				if v, err = ints(4); err == nil {
+					if v[0] < 0 || v[1] < 0 || v[0] > bdfMaxGlyphSize || v[1] > bdfMaxGlyphSize {
+						return nil, fmt.Errorf("line %d: invalid glyph size %dx%d", line, v[0], v[1])
+					}
+					g.width, g.height = v[0], v[1]
+					// The bitmap's bottom left corner is offset from the pen, with y increasing upwards
+					g.originX, g.originY = -v[2], v[1]+v[3]
+				}
+			}
+		case "BITMAP":
+			inBitmap = g != nil
+		case "ENDCHAR":
+			if g != nil && encoding >= 0 {
+				g.bitmap = unpackBits(bitmap, g.width, g.height)
+				glyphs[encoding] = g
+			}
+			g = nil
+		}
+		if err != nil {
+			return nil, err
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+	if ascent < 0 {
+		ascent = boxHeight + boxY
+	}
+	if descent < 0 {
+		descent = -boxY
+	}
+	return newFont(glyphs, ascent, descent, fallback)
+}
@@ -0,0 +1,118 @@
+package pixman
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+)
+
+// GlyphCache holds rendered glyph images inside pixman, so that a run of text can be composited in a single call.
+// Glyphs are identified by a pair of keys chosen by the caller, typically one for the font and one for the character.
+// Insert may only be called while the cache is frozen, and the cache may evict glyphs when it is thawed, so
+// a typical frame freezes the cache, looks up or inserts every glyph needed, composites them and then thaws it.
+type GlyphCache struct {
+	cache  *PixmanGlyphCache
+	frozen int // Depth of nested Freeze calls, as pixman reports inserting into a thawed cache as a bug
+}
+
+// NewGlyphCache creates an empty glyph cache
+func NewGlyphCache() (*GlyphCache, error) {
+	retval := &GlyphCache{}
+	retval.cache = GlyphCacheCreate()
+	if retval.cache == nil {
+		return nil, fmt.Errorf("failed to create Pixman glyph cache")
+	}
+	runtime.AddCleanup(retval, func(raw *PixmanGlyphCache) {
+		GlyphCacheDestroy(raw)
+	}, retval.cache)
+	return retval, nil
+}
+
+// Freeze prevents glyphs from being evicted until the matching call to Thaw. Calls may be nested.
+func (c *GlyphCache) Freeze() {
+	GlyphCacheFreeze(c.cache)
+	c.frozen++
+}
+
+// Thaw undoes a call to Freeze. Once the cache is no longer frozen it may evict glyphs if it has grown too large,
+// invalidating any glyph handles previously returned. Thawing a cache that isn't frozen does nothing.
+func (c *GlyphCache) Thaw() {
+	if c.frozen == 0 {
+		return
+	}
+	c.frozen--
+	GlyphCacheThaw(c.cache)
+	runtime.KeepAlive(c)
+}
+
+// Lookup returns the handle of the glyph stored under the given keys, and whether it was found
+func (c *GlyphCache) Lookup(fontKey, glyphKey uintptr) (uintptr, bool) {
+	glyph := GlyphCacheLookup(c.cache, fontKey, glyphKey)
+	runtime.KeepAlive(c)
+	return glyph, glyph != 0
+}
+
+// Insert copies img into the cache under the given keys and returns its handle. (originX, originY) is the point
+// within img that is placed at a glyph's position when it is composited. The cache must be frozen, and holds at
+// most 32768 glyphs.
+func (c *GlyphCache) Insert(fontKey, glyphKey uintptr, originX, originY int, img *Image) (uintptr, error) {
+	if c.frozen == 0 {
+		return 0, fmt.Errorf("glyph cache must be frozen to insert glyphs")
+	}
+	glyph := GlyphCacheInsert(c.cache, fontKey, glyphKey, int32(originX), int32(originY), img.pixman)
+	runtime.KeepAlive(c)
+	runtime.KeepAlive(img)
+	if glyph == 0 {
+		return 0, fmt.Errorf("failed to insert glyph into cache, which may be full or out of memory")
+	}
+	return glyph, nil
+}
+
+// Remove discards the glyph stored under the given keys, if any
+func (c *GlyphCache) Remove(fontKey, glyphKey uintptr) {
+	GlyphCacheRemove(c.cache, fontKey, glyphKey)
+	runtime.KeepAlive(c)
+}
+
+// Extents returns the bounding box of glyphs when composited
+func (c *GlyphCache) Extents(glyphs []PixmanGlyph) image.Rectangle {
+	if len(glyphs) == 0 {
+		return image.Rectangle{}
+	}
+	var box PixmanBox32
+	GlyphGetExtents(c.cache, int32(len(glyphs)), &glyphs[0], &box)
+	runtime.KeepAlive(c)
+	return rectFromBox(box)
+}
+
+// MaskFormat returns the format pixman needs for the intermediate mask when compositing glyphs.
+// This is PIXMAN_a8 unless the glyphs hold colour or component alpha.
+func (c *GlyphCache) MaskFormat(glyphs []PixmanGlyph) PixmanFormatCode {
+	if len(glyphs) == 0 {
+		return PIXMAN_a8
+	}
+	defer runtime.KeepAlive(c)
+	return GlyphGetMaskFormat(c.cache, int32(len(glyphs)), &glyphs[0])
+}
+
+// CompositeGlyphs composites src onto dst using op, through a mask built from glyphs held in cache.
+// Glyph positions are in dst space, and src is aligned with dst. Pixman allocates the mask to cover the
+// glyphs, so it is limited to the part of them inside dst.
+func CompositeGlyphs(op PixmanOperation, src, dst *Image, cache *GlyphCache, glyphs []PixmanGlyph) error {
+	for i, g := range glyphs {
+		if g.Glyph == 0 {
+			return fmt.Errorf("glyph %d has no glyph cache handle", i)
+		}
+	}
+	box := cache.Extents(glyphs).Intersect(dst.Bounds())
+	if box.Empty() {
+		return nil
+	}
+	pixmanCompositeGlyphs(op, src.pixman, dst.pixman, cache.MaskFormat(glyphs),
+		int32(box.Min.X), int32(box.Min.Y), int32(box.Min.X), int32(box.Min.Y), int32(box.Min.X), int32(box.Min.Y),
+		int32(box.Dx()), int32(box.Dy()), cache.cache, int32(len(glyphs)), &glyphs[0])
+	runtime.KeepAlive(src)
+	runtime.KeepAlive(dst)
+	runtime.KeepAlive(cache)
+	return nil
+}
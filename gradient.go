@@ -0,0 +1,80 @@
+package pixman
+
+import (
+	"fmt"
+	"image/color"
+	"runtime"
+	"sort"
+)
+
+// GradientStop describes the colour of a gradient at Offset, which ranges from 0 at the start of the gradient to 1 at the end
+type GradientStop struct {
+	Offset float64
+	Color  color.Color
+}
+
+// pixmanStops converts stops to their pixman representation, sorted by offset.
+// Unlike solid fills, pixman expects gradient stop colours to not be premultiplied.
+func pixmanStops(stops []GradientStop) ([]PixmanGradientStop, error) {
+	if len(stops) == 0 {
+		return nil, fmt.Errorf("gradient requires at least one stop")
+	}
+	retval := make([]PixmanGradientStop, len(stops))
+	for i, stop := range stops {
+		col := color.NRGBA64Model.Convert(stop.Color).(color.NRGBA64)
+		retval[i] = PixmanGradientStop{
+			X: FixedFromFloat(stop.Offset),
+			Color: PixmanColor{
+				Red:   col.R,
+				Green: col.G,
+				Blue:  col.B,
+				Alpha: col.A,
+			},
+		}
+	}
+	sort.SliceStable(retval, func(i, j int) bool {
+		return retval[i].X < retval[j].X
+	})
+	return retval, nil
+}
+
+// gradientImage wraps a newly created pixman gradient, releasing it once the Image is garbage collected
+func gradientImage(raw *PixmanImage, kind string) (*Image, error) {
+	if raw == nil {
+		return nil, fmt.Errorf("failed to create Pixman %s gradient image", kind)
+	}
+	retval := &Image{pixman: raw}
+	runtime.AddCleanup(retval, func(raw *PixmanImage) {
+		ImageUnref(raw)
+	}, retval.pixman)
+	return retval, nil
+}
+
+// ImageLinearGradient creates a source image that blends between stops along the line from p1 to p2
+func ImageLinearGradient(p1, p2 PixmanPointFixed, stops []GradientStop) (*Image, error) {
+	pixStops, err := pixmanStops(stops)
+	if err != nil {
+		return nil, err
+	}
+	return gradientImage(ImageCreateLinearGradient(&p1, &p2, &pixStops[0], int32(len(pixStops))), "linear")
+}
+
+// ImageRadialGradient creates a source image that blends between stops from the circle at inner with
+// radius innerRadius to the circle at outer with radius outerRadius
+func ImageRadialGradient(inner, outer PixmanPointFixed, innerRadius, outerRadius PixmanFixed, stops []GradientStop) (*Image, error) {
+	pixStops, err := pixmanStops(stops)
+	if err != nil {
+		return nil, err
+	}
+	return gradientImage(ImageCreateRadialGradient(&inner, &outer, innerRadius, outerRadius, &pixStops[0], int32(len(pixStops))), "radial")
+}
+
+// ImageConicalGradient creates a source image that blends between stops as it sweeps around center,
+// starting at angle degrees
+func ImageConicalGradient(center PixmanPointFixed, angle PixmanFixed, stops []GradientStop) (*Image, error) {
+	pixStops, err := pixmanStops(stops)
+	if err != nil {
+		return nil, err
+	}
+	return gradientImage(ImageCreateConicalGradient(&center, angle, &pixStops[0], int32(len(pixStops))), "conical")
+}
@@ -8,12 +8,26 @@ struct pixman_formats {
 #define FORMAT(a) { a, #a }
 
 #define FORMATS \
+    FORMAT(PIXMAN_rgba_float), \
+    FORMAT(PIXMAN_rgb_float), \
+    FORMAT(PIXMAN_a16b16g16r16), \
     FORMAT(PIXMAN_a8r8g8b8), \
     FORMAT(PIXMAN_x8r8g8b8), \
     FORMAT(PIXMAN_a8b8g8r8), \
     FORMAT(PIXMAN_x8b8g8r8), \
     FORMAT(PIXMAN_b8g8r8a8), \
     FORMAT(PIXMAN_b8g8r8x8), \
+    FORMAT(PIXMAN_r8g8b8a8), \
+    FORMAT(PIXMAN_r8g8b8x8), \
+    FORMAT(PIXMAN_x14r6g6b6), \
+    FORMAT(PIXMAN_x2r10g10b10), \
+    FORMAT(PIXMAN_a2r10g10b10), \
+    FORMAT(PIXMAN_x2b10g10r10), \
+    FORMAT(PIXMAN_a2b10g10r10), \
+    FORMAT(PIXMAN_a8r8g8b8_sRGB), \
+    FORMAT(PIXMAN_r8g8b8_sRGB), \
+    FORMAT(PIXMAN_r8g8b8), \
+    FORMAT(PIXMAN_b8g8r8), \
     FORMAT(PIXMAN_r5g6b5), \
     FORMAT(PIXMAN_b5g6r5), \
     FORMAT(PIXMAN_a1r5g5b5), \
@@ -24,8 +38,27 @@ struct pixman_formats {
     FORMAT(PIXMAN_x4r4g4b4), \
     FORMAT(PIXMAN_a4b4g4r4), \
     FORMAT(PIXMAN_x4b4g4r4), \
-    FORMAT(PIXMAN_r8g8b8a8), \
-    FORMAT(PIXMAN_r8g8b8x8)
+    FORMAT(PIXMAN_a8), \
+    FORMAT(PIXMAN_r3g3b2), \
+    FORMAT(PIXMAN_b2g3r3), \
+    FORMAT(PIXMAN_a2r2g2b2), \
+    FORMAT(PIXMAN_a2b2g2r2), \
+    FORMAT(PIXMAN_c8), \
+    FORMAT(PIXMAN_g8), \
+    FORMAT(PIXMAN_x4a4), \
+    FORMAT(PIXMAN_x4c4), \
+    FORMAT(PIXMAN_x4g4), \
+    FORMAT(PIXMAN_a4), \
+    FORMAT(PIXMAN_r1g2b1), \
+    FORMAT(PIXMAN_b1g2r1), \
+    FORMAT(PIXMAN_a1r1g1b1), \
+    FORMAT(PIXMAN_a1b1g1r1), \
+    FORMAT(PIXMAN_c4), \
+    FORMAT(PIXMAN_g4), \
+    FORMAT(PIXMAN_a1), \
+    FORMAT(PIXMAN_g1), \
+    FORMAT(PIXMAN_yuy2), \
+    FORMAT(PIXMAN_yv12)
 
 
 int main(void)
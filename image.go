@@ -1,27 +1,24 @@
 package pixman
 
 import (
+	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
-	"log"
+	"math"
 	"os"
-	"unsafe"
+	"runtime"
 )
 
 var _ draw.Image = (*Image)(nil)
 
+// ColorModel returns the model matching the precision of the image format, such as RGB565Model for PIXMAN_r5g6b5.
+// Formats without a pixel codec use color.RGBA64Model.
 func (i *Image) ColorModel() color.Model {
-	format := ImageGetFormat(i.pixman)
-	switch format {
-	case PIXMAN_a8r8g8b8, PIXMAN_x8r8g8b8, PIXMAN_a8b8g8r8, PIXMAN_x8b8g8r8:
-		return color.RGBAModel
-	case PIXMAN_b5g6r5, PIXMAN_r5g6b5:
-		return color.RGBAModel // 16-bit formats, treated as RGB
-	default:
-		// TODO: Handle other formats
-		return color.NRGBA64Model
+	if codec, ok := pixelCodecs[ImageGetFormat(i.pixman)]; ok {
+		return codec.model
 	}
+	return color.RGBA64Model
 }
 
 func (i *Image) Bounds() image.Rectangle {
@@ -51,116 +48,325 @@ func (i *Image) getRawData() []byte {
 	*/
 }
 
-func (i *Image) At(x, y int) color.Color {
-	stride := ImageGetStride(i.pixman)
-	depth := ImageGetDepth(i.pixman)
-	if x < 0 || y < 0 || x >= int(ImageGetWidth(i.pixman)) || y >= int(ImageGetHeight(i.pixman)) {
-		return color.Transparent
+// clone returns a copy of this image's pixels, without any transform or filter settings
+func (i *Image) clone() (*Image, error) {
+	rawData := i.getRawData()
+	if len(rawData) == 0 {
+		return nil, fmt.Errorf("image has no pixel data to copy")
 	}
-	if stride <= 0 || depth <= 0 {
-		return color.Transparent
+	bounds := i.Bounds()
+	retval, err := imageCreate(ImageGetFormat(i.pixman), bounds.Dx(), bounds.Dy())
+	if err != nil {
+		return nil, err
 	}
-	offset := y*int(stride) + x*int(depth)/8
-	rawData := i.getRawData()
+	srcStride := int(ImageGetStride(i.pixman))
+	dstStride := int(ImageGetStride(retval.pixman))
+	rowBytes := min(srcStride, dstStride)
+	for y := range bounds.Dy() {
+		copy(retval.rawData[y*dstStride:y*dstStride+rowBytes], rawData[y*srcStride:y*srcStride+rowBytes])
+	}
+	// The pixels may belong to pixman, which releases them along with the image
+	runtime.KeepAlive(i)
+	return retval, nil
+}
 
-	var col color.Color
+// pixelOffset returns the offset of pixel (x, y) in the image data, and the codec for its format.
+// It returns false if the point is outside the image or the format isn't supported.
+func (i *Image) pixelOffset(x, y int) (int, pixelCodec, bool) {
+	if x < 0 || y < 0 || x >= int(ImageGetWidth(i.pixman)) || y >= int(ImageGetHeight(i.pixman)) {
+		return 0, pixelCodec{}, false
+	}
+	stride := int(ImageGetStride(i.pixman))
+	codec, ok := pixelCodecs[ImageGetFormat(i.pixman)]
+	if stride <= 0 || !ok {
+		return 0, pixelCodec{}, false
+	}
+	offset := y*stride + x*codec.bytes
+	if offset+codec.bytes > len(i.getRawData()) {
+		return 0, pixelCodec{}, false
+	}
+	return offset, codec, true
+}
 
-	switch ImageGetFormat(i.pixman) {
-	case PIXMAN_r8g8b8a8:
-		col = color.RGBA{
-			R: rawData[offset],
-			G: rawData[offset+1],
-			B: rawData[offset+2],
-			A: rawData[offset+3],
-		}
-	case PIXMAN_r8g8b8x8:
-		col = color.NRGBA{
-			R: rawData[offset],
-			G: rawData[offset+1],
-			B: rawData[offset+2],
-			A: 255,
-		}
-	//case PIXMAN_b5g6r5:
-	//col = color.RGBA{
-	//R: (rawData[offset+1] & 0x1F) << 3,                            // Red bits
-	//G: ((rawData[offset+1] >> 5) | (rawData[offset] & 0x03)) << 2, // Green bits
-	//B: (rawData[offset] >> 2) & 0x1F << 3,                         // Blue bits
-	//A: 255,                                                        // No alpha in this format
-	//}
-	case PIXMAN_r5g6b5:
-		//log.Printf("RGB565 at %d,%d: %02x %02x", x, y, rawData[offset], rawData[offset+1])
-		rgba := color.RGBA{
-			R: (rawData[offset+1] & 0xf8),
-			G: ((rawData[offset+1] & 0x7) << 5) | (rawData[offset]&0xe0)>>3,
-			B: (rawData[offset] & 0x1f) << 3,
-			A: 255, // No alpha in this format
-		}
-		if rgba.R&0x08 != 0 {
-			rgba.R |= 0x07
-		}
-		if rgba.G&0x04 != 0 {
-			rgba.G |= 0x03
-		}
-		if rgba.B&0x08 != 0 {
-			rgba.B |= 0x07
+// At returns the colour of the pixel at (x, y), in the image's ColorModel. Points outside the image read as
+// color.Transparent, and pixels of formats without a pixel codec read as transparent in the image's ColorModel.
+func (i *Image) At(x, y int) color.Color {
+	offset, codec, ok := i.pixelOffset(x, y)
+	if !ok {
+		if !image.Pt(x, y).In(i.Bounds()) {
+			return color.Transparent
 		}
-		col = rgba
-		//log.Printf("RGB565 color at %d,%d: %v", x, y, col)
-	default:
-		col = color.Transparent // Unsupported format
+		return i.ColorModel().Convert(color.Transparent)
 	}
-	return col
+	pixel := codec.load(i.getRawData()[offset:])
+	// The pixels may belong to pixman, which releases them along with the image
+	runtime.KeepAlive(i)
+	return codec.model.Convert(codec.decode(pixel))
 }
 
+// Set stores c at (x, y), truncating each channel to the precision of the image format.
+// Points outside the image, and images in formats without a pixel codec, are left unchanged.
 func (i *Image) Set(x, y int, c color.Color) {
-	stride := ImageGetStride(i.pixman)
-	depth := ImageGetDepth(i.pixman)
-	if x < 0 || y < 0 || x >= int(ImageGetWidth(i.pixman)) || y >= int(ImageGetHeight(i.pixman)) {
+	offset, codec, ok := i.pixelOffset(x, y)
+	if !ok {
 		return
 	}
-	if stride <= 0 || depth <= 0 {
-		return
+	codec.store(i.getRawData()[offset:], codec.encode(c))
+	runtime.KeepAlive(i)
+}
+
+// Composite performs a blit operation from the sub-image of `src` defined by `r`, placing the result at the point `sp` in this image.
+func (i *Image) Composite(src *Image, r image.Rectangle, sp image.Point) {
+	i.CompositeOp(PIXMAN_OP_OVER, src, nil, r.Min, image.Point{}, sp, r.Size())
+}
+
+// CompositeOp combines a `size` area of `src` starting at `srcPt` with this image at `dstPt`, using the operator `op`.
+// If `mask` is not nil, the source is first multiplied by the alpha of `mask`, starting at `maskPt`.
+func (i *Image) CompositeOp(op PixmanOperation, src, mask *Image, srcPt, maskPt, dstPt image.Point, size image.Point) {
+	var maskImage *PixmanImage
+	if mask != nil {
+		maskImage = mask.pixman
+	}
+	ImageComposite32(op, src.pixman, maskImage, i.pixman,
+		int32(srcPt.X), int32(srcPt.Y), // src_x, src_y (source rectangle)
+		int32(maskPt.X), int32(maskPt.Y), // mask_x, mask_y (mask rectangle)
+		int32(dstPt.X), int32(dstPt.Y), // dest_x, dest_y (destination point)
+		int32(size.X), int32(size.Y)) // width, height (rectangle size)
+	// Once their pixman images have been read, the Go images could otherwise be collected during the call,
+	// releasing the pixels pixman is still using
+	runtime.KeepAlive(i)
+	runtime.KeepAlive(src)
+	runtime.KeepAlive(mask)
+}
+
+// SetTransform sets the transform used when sampling this image as a composite source.
+// The transform maps destination coordinates to source coordinates. A nil transform restores the identity.
+func (i *Image) SetTransform(t *Transform) error {
+	ok := ImageSetTransform(i.pixman, t)
+	runtime.KeepAlive(i)
+	if !ok {
+		return fmt.Errorf("failed to set transform %v", t)
+	}
+	return nil
+}
+
+// Transform returns the transform currently set on this image
+func (i *Image) Transform() Transform {
+	// The transform is stored inside the pixman image, so copy it before the image can be released
+	defer runtime.KeepAlive(i)
+	t := ImageGetTransform(i.pixman)
+	if t == nil {
+		return TransformIdentity()
+	}
+	return *t
+}
+
+// SetFilter selects the filter used to sample this image when it is transformed
+func (i *Image) SetFilter(filter PixmanFilter) error {
+	ok := ImageSetFilter(i.pixman, filter, nil, 0)
+	runtime.KeepAlive(i)
+	if !ok {
+		return fmt.Errorf("failed to set filter %s", filter)
+	}
+	return nil
+}
+
+// SetClipRegion restricts Composite and Fill to only modify the pixels of this image within region.
+// Pixman keeps its own copy of the region, so later changes to region have no effect.
+func (i *Image) SetClipRegion(region *Region) error {
+	ok := ImageSetClipRegion32(i.pixman, region.region)
+	runtime.KeepAlive(i)
+	runtime.KeepAlive(region)
+	if !ok {
+		return fmt.Errorf("failed to set clip region %v", region)
+	}
+	return nil
+}
+
+// ClearClip removes any clip region, so the whole image may be modified
+func (i *Image) ClearClip() {
+	ImageSetClipRegion32(i.pixman, nil)
+	runtime.KeepAlive(i)
+}
+
+// SetSourceClipping controls whether the clip region also restricts the pixels read when this image is a composite source.
+// Pixman only applies source clipping to clip regions marked as client clips, see SetHasClientClip.
+func (i *Image) SetSourceClipping(enabled bool) {
+	ImageSetSourceClipping(i.pixman, enabled)
+	runtime.KeepAlive(i)
+}
+
+// SetHasClientClip marks whether the clip region was set explicitly by the client, rather than derived from a window hierarchy
+func (i *Image) SetHasClientClip(clientClip bool) {
+	ImageSetHasClientClip(i.pixman, clientClip)
+	runtime.KeepAlive(i)
+}
+
+// SetComponentAlpha controls whether this image, when used as a composite mask, applies each of its colour
+// channels as the coverage of the matching channel of the source, rather than applying its alpha to all channels.
+// This is how subpixel anti-aliased text is composited.
+func (i *Image) SetComponentAlpha(enabled bool) {
+	ImageSetComponentAlpha(i.pixman, enabled)
+	runtime.KeepAlive(i)
+}
+
+// ComponentAlpha reports whether this image is used as a per-channel mask, see SetComponentAlpha
+func (i *Image) ComponentAlpha() bool {
+	defer runtime.KeepAlive(i)
+	return ImageGetComponentAlpha(i.pixman)
+}
+
+// SetAlphaMap makes this image take its alpha channel from alpha instead of its own pixels, both when it is
+// read as a composite source or mask and when it is written as a destination. Pixel (0, 0) of alpha lines up with
+// origin in this image. Colour channels are still read from this image and must already be premultiplied by the
+// alpha they are paired with. Passing nil removes the alpha map.
+func (i *Image) SetAlphaMap(alpha *Image, origin image.Point) error {
+	if alpha == nil {
+		ImageSetAlphaMap(i.pixman, nil, 0, 0)
+		runtime.KeepAlive(i)
+		i.alphaMap = nil
+		return nil
+	}
+	if alpha == i {
+		return fmt.Errorf("an image cannot be its own alpha map")
+	}
+	if len(alpha.getRawData()) == 0 {
+		return fmt.Errorf("alpha map must be an image with pixel data")
+	}
+	if origin.X < math.MinInt16 || origin.X > math.MaxInt16 || origin.Y < math.MinInt16 || origin.Y > math.MaxInt16 {
+		return fmt.Errorf("alpha map origin %v is out of range", origin)
+	}
+	ImageSetAlphaMap(i.pixman, alpha.pixman, int16(origin.X), int16(origin.Y))
+	runtime.KeepAlive(i)
+	i.alphaMap = alpha
+	return nil
+}
+
+// SetSourceClipRegion sets region as a client clip and enables source clipping, so that compositing
+// from this image only touches destination pixels whose corresponding source pixels lie within region.
+// The clip is applied in untransformed source coordinates, and only limits which destination pixels are
+// written: filters such as bilinear or convolution still sample source pixels just outside region when
+// computing pixels near its edge. Use Sprite to draw part of an image with a filter and no bleeding.
+func (i *Image) SetSourceClipRegion(region *Region) error {
+	if err := i.SetClipRegion(region); err != nil {
+		return err
+	}
+	i.SetHasClientClip(true)
+	i.SetSourceClipping(true)
+	return nil
+}
+
+// Sprite copies the pixels of r into a new image whose edge pixels repeat outwards (PIXMAN_REPEAT_PAD), so that
+// compositing it through a bilinear or convolution filter never blends in pixels of this image from outside r.
+// This isolates one sprite of a sprite sheet from its neighbours. Pixel (0, 0) of the sprite is r.Min in this image.
+func (i *Image) Sprite(r image.Rectangle) (*Image, error) {
+	if r.Empty() || !r.In(i.Bounds()) {
+		return nil, fmt.Errorf("sprite %v is not within the image bounds %v", r, i.Bounds())
+	}
+	format := ImageGetFormat(i.pixman)
+	if format.BPP() < 8 || format.BPP()%8 != 0 {
+		return nil, fmt.Errorf("sprites of %s images are not supported", format)
 	}
-	offset := y*int(stride) + x*int(depth)/8
 	rawData := i.getRawData()
+	if len(rawData) == 0 {
+		return nil, fmt.Errorf("image has no pixel data to copy")
+	}
+	retval, err := imageCreate(format, r.Dx(), r.Dy())
+	if err != nil {
+		return nil, err
+	}
+	pixelBytes := format.BPP() / 8
+	srcStride := int(ImageGetStride(i.pixman))
+	dstStride := int(ImageGetStride(retval.pixman))
+	rowBytes := r.Dx() * pixelBytes
+	for y := range r.Dy() {
+		start := (r.Min.Y+y)*srcStride + r.Min.X*pixelBytes
+		copy(retval.rawData[y*dstStride:y*dstStride+rowBytes], rawData[start:start+rowBytes])
+	}
+	// The pixels may belong to pixman, which releases them along with the image
+	runtime.KeepAlive(i)
+	retval.SetRepeat(PIXMAN_REPEAT_PAD)
+	return retval, nil
+}
+
+// SetRepeat controls how this image is sampled outside of its bounds when used as a composite source
+func (i *Image) SetRepeat(repeat PixmanRepeat) {
+	ImageSetRepeat(i.pixman, repeat)
+	runtime.KeepAlive(i)
+}
 
-	colR, colG, colB, colA := c.RGBA()
-	colR8 := uint8(colR >> 8)
-	colG8 := uint8(colG >> 8)
-	colB8 := uint8(colB >> 8)
-	colA8 := uint8(colA >> 8)
-
-	switch ImageGetFormat(i.pixman) {
-	case PIXMAN_r8g8b8a8:
-		rawData[offset] = colR8
-		rawData[offset+1] = colG8
-		rawData[offset+2] = colB8
-		rawData[offset+3] = colA8
-	case PIXMAN_r8g8b8x8:
-		rawData[offset] = colR8
-		rawData[offset+1] = colG8
-		rawData[offset+2] = colB8
-		rawData[offset+3] = 255
-	//case PIXMAN_b5g6r5:
-	//rawData[offset] = colB8&0xf8 | colG8>>5
-	//rawData[offset+1] = colG8&0x1c<<3 | colR8>>3
-	//case PIXMAN_r5g6b5:
-	//rawData[offset] = colR8&0xf8 | (colG8>>5)&0x07
-	//rawData[offset+1] = colG8&0x1c<<3 | colB8>>3
-	default:
-		log.Printf("Unsupported format for Set: %s", ImageGetFormat(i.pixman))
-		// Unsupported format, do nothing
+// SetSeparableFilter configures a separable convolution filter for this image. Each source pixel is
+// reconstructed with the reconstruct kernels, then sampled with the sample kernels, horizontally and
+// vertically. PIXMAN_KERNEL_BOX is the usual reconstruct kernel. The filter is sized for the image's
+// current transform, so SetTransform should be called first. Each pixel is subdivided into
+// 2^subsampleBits phases; 4 is a reasonable default.
+func (i *Image) SetSeparableFilter(reconstructX, reconstructY, sampleX, sampleY PixmanKernel, subsampleBits int) error {
+	// The transform maps destination to source, so the length of each row gives the number of
+	// source pixels along that axis covered by a destination pixel, as cairo computes it
+	t := i.Transform()
+	scaleX := math.Hypot(t.Matrix[0][0].Float(), t.Matrix[0][1].Float())
+	scaleY := math.Hypot(t.Matrix[1][0].Float(), t.Matrix[1][1].Float())
+
+	params, err := separableConvolution(FixedFromFloat(scaleX), FixedFromFloat(scaleY),
+		reconstructX, reconstructY, sampleX, sampleY, subsampleBits, subsampleBits)
+	if err != nil {
+		return err
+	}
+
+	ok := ImageSetFilter(i.pixman, PIXMAN_FILTER_SEPARABLE_CONVOLUTION, &params[0], int32(len(params)))
+	runtime.KeepAlive(i)
+	if !ok {
+		return fmt.Errorf("failed to set separable convolution filter")
 	}
+	return nil
 }
 
-// Composite performs a blit operation from the sub-image of `src` defined by `r`, placing the result at the point `sp` in this image.
-func (i *Image) Composite(src *Image, r image.Rectangle, sp image.Point) {
-	ImageComposite32(PIXMAN_OP_OVER, src.pixman, nil, i.pixman,
-		int32(r.Min.X), int32(r.Min.Y), // src_x, src_y (source rectangle)
-		0, 0, // mask_x, mask_y (no mask)
-		int32(sp.X), int32(sp.Y), // dest_x, dest_y (destination point)
-		int32(r.Dx()), int32(r.Dy())) // width, height (rectangle size)
+// convolutionParams packs kernel into the PIXMAN_FILTER_CONVOLUTION parameters: the kernel dimensions
+// followed by the weights, all in fixed point. The weights are rounded, and the rounding error is put on
+// the centre tap so that the fixed point weights sum to the same as the kernel. Otherwise a normalised
+// kernel would sum to less than one, and darken every image it filters.
+func convolutionParams(kernel [][]float64) ([]PixmanFixed, error) {
+	height := len(kernel)
+	if height == 0 || height%2 == 0 {
+		return nil, fmt.Errorf("convolution kernel height %d must be odd", height)
+	}
+	width := len(kernel[0])
+	if width == 0 || width%2 == 0 {
+		return nil, fmt.Errorf("convolution kernel width %d must be odd", width)
+	}
+	params := make([]PixmanFixed, 0, 2+width*height)
+	params = append(params, FixedFromInt(width), FixedFromInt(height))
+	sum := 0.0
+	fixedSum := PixmanFixed(0)
+	for y, row := range kernel {
+		if len(row) != width {
+			return nil, fmt.Errorf("convolution kernel row %d has width %d, expected %d", y, len(row), width)
+		}
+		for _, weight := range row {
+			fixed := PixmanFixed(math.Round(weight * 65536))
+			params = append(params, fixed)
+			sum += weight
+			fixedSum += fixed
+		}
+	}
+	params[2+height/2*width+width/2] += PixmanFixed(math.Round(sum*65536)) - fixedSum
+	return params, nil
+}
 
+// SetConvolution configures a convolution filter, so that each pixel sampled from this image is the
+// weighted sum of its neighbours. The kernel is indexed as kernel[y][x], must be rectangular, and has
+// odd dimensions so that it is centred on the sampled pixel.
+func (i *Image) SetConvolution(kernel [][]float64) error {
+	params, err := convolutionParams(kernel)
+	if err != nil {
+		return err
+	}
+	ok := ImageSetFilter(i.pixman, PIXMAN_FILTER_CONVOLUTION, &params[0], int32(len(params)))
+	runtime.KeepAlive(i)
+	if !ok {
+		return fmt.Errorf("failed to set %dx%d convolution filter", params[0].Int(), params[1].Int())
+	}
+	return nil
 }
 
 func (i *Image) SaveRaw(filename string) error {
@@ -170,13 +376,28 @@ func (i *Image) SaveRaw(filename string) error {
 	return nil
 }
 
+// Fill sets the pixels of rect to col, within the clip region if one is set
 func (i *Image) Fill(rect image.Rectangle, col color.Color) {
-	rawData := i.getRawData()
-	r, g, b, a := col.RGBA()
-	col32 := uint32((a>>8)&0xff)<<24 | // Alpha
-		uint32((b>>8)&0xff)<<16 | // Blue
-		uint32((g>>8)&0xff)<<8 | // Green
-		uint32((r>>8)&0xff) // Red
-	stride := int(ImageGetStride(i.pixman) / 4) // Rowstride in 32-bit units
-	Fill((*uint32)(unsafe.Pointer(&rawData[0])), stride, i.Depth(), rect.Min.X, rect.Min.Y, rect.Dx(), rect.Dy(), col32)
+	_ = i.FillBoxes(PIXMAN_OP_SRC, []image.Rectangle{rect}, col)
+}
+
+// FillBoxes combines col into the pixels of each of rects using op, within the clip region if one is set.
+// Rectangles are clipped to the image bounds, which pixman does not do itself when there is no clip region.
+func (i *Image) FillBoxes(op PixmanOperation, rects []image.Rectangle, col color.Color) error {
+	boxes := make([]PixmanBox32, 0, len(rects))
+	for _, rect := range rects {
+		if rect = rect.Intersect(i.Bounds()); !rect.Empty() {
+			boxes = append(boxes, boxFromRect(rect))
+		}
+	}
+	if len(boxes) == 0 {
+		return nil
+	}
+	pixCol := pixmanColor(col)
+	ok := ImageFillBoxes(op, i.pixman, &pixCol, int32(len(boxes)), &boxes[0])
+	runtime.KeepAlive(i)
+	if !ok {
+		return fmt.Errorf("failed to fill %d boxes", len(boxes))
+	}
+	return nil
 }
@@ -0,0 +1,23 @@
+package pixman
+
+// DrawLine composites src over dst along an anti-aliased line from p0 to p1, width pixels wide with flat (butt) ends.
+// Coordinates are in dst space, and src is aligned with dst.
+func DrawLine(dst *Image, p0, p1 PointF, width float64, src *Image) {
+	DrawPolyline(dst, []PointF{p0, p1}, width, src)
+}
+
+// DrawPolyline composites src over dst along anti-aliased lines joining each of points to the next, width
+// pixels wide with bevelled corners and flat (butt) ends. Coordinates are in dst space, and src is aligned with dst.
+// The outline is filled as a single shape, so corners and places where the line crosses itself are covered
+// exactly once.
+func DrawPolyline(dst *Image, points []PointF, width float64, src *Image) {
+	if len(points) == 0 {
+		return
+	}
+	var path Path
+	path.MoveTo(points[0].X, points[0].Y)
+	for _, pt := range points[1:] {
+		path.LineTo(pt.X, pt.Y)
+	}
+	StrokePath(dst, &path, src, StrokeStyle{Width: width, Join: JoinBevel, Cap: CapButt})
+}
@@ -0,0 +1,402 @@
+package pixman
+
+import (
+	"fmt"
+	"image"
+	"math"
+	"sort"
+)
+
+// PointF is a point with floating point coordinates
+type PointF struct {
+	X float64
+	Y float64
+}
+
+// FillRule determines which areas enclosed by a path are filled
+type FillRule uint32
+
+const (
+	// FillRuleNonZero fills areas that the path winds around a non-zero number of times
+	FillRuleNonZero FillRule = iota
+	// FillRuleEvenOdd fills areas that are enclosed by an odd number of path segments
+	FillRuleEvenOdd
+)
+
+func (r FillRule) String() string {
+	switch r {
+	case FillRuleNonZero:
+		return "FillRuleNonZero"
+	case FillRuleEvenOdd:
+		return "FillRuleEvenOdd"
+	default:
+		return fmt.Sprintf("Unknown FillRule: %d", uint32(r))
+	}
+}
+
+// Curves are flattened into line segments that deviate from the true curve by at most this many pixels
+const flattenTolerance = 0.1
+
+type subpath struct {
+	points []PointF
+	closed bool
+}
+
+// Path is a sequence of subpaths made of straight lines and bezier curves. The zero value is an empty path.
+// Curves are flattened into line segments as they are added.
+type Path struct {
+	subpaths []subpath
+}
+
+// current returns the subpath being extended, starting a new one if the last subpath was closed
+func (p *Path) current() *subpath {
+	if len(p.subpaths) == 0 {
+		return nil
+	}
+	last := &p.subpaths[len(p.subpaths)-1]
+	if last.closed {
+		// Drawing after Close continues from the start of the closed subpath
+		p.subpaths = append(p.subpaths, subpath{points: []PointF{last.points[0]}})
+		last = &p.subpaths[len(p.subpaths)-1]
+	}
+	return last
+}
+
+// MoveTo starts a new subpath at (x, y)
+func (p *Path) MoveTo(x, y float64) {
+	p.subpaths = append(p.subpaths, subpath{points: []PointF{{X: x, Y: y}}})
+}
+
+// LineTo adds a straight line from the current point to (x, y)
+func (p *Path) LineTo(x, y float64) {
+	sp := p.current()
+	if sp == nil {
+		p.MoveTo(x, y)
+		return
+	}
+	sp.points = append(sp.points, PointF{X: x, Y: y})
+}
+
+// QuadTo adds a quadratic bezier curve from the current point to (x, y), with control point (cx, cy)
+func (p *Path) QuadTo(cx, cy, x, y float64) {
+	sp := p.current()
+	if sp == nil {
+		p.MoveTo(cx, cy)
+		sp = p.current()
+	}
+	p0 := sp.points[len(sp.points)-1]
+	// The second difference bounds how far the curve strays from its chords
+	dd := math.Hypot(p0.X-2*cx+x, p0.Y-2*cy+y)
+	n := curveSegments(dd / (4 * flattenTolerance))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		sp.points = append(sp.points, PointF{
+			X: mt*mt*p0.X + 2*mt*t*cx + t*t*x,
+			Y: mt*mt*p0.Y + 2*mt*t*cy + t*t*y,
+		})
+	}
+}
+
+// CubicTo adds a cubic bezier curve from the current point to (x, y), with control points (c1x, c1y) and (c2x, c2y)
+func (p *Path) CubicTo(c1x, c1y, c2x, c2y, x, y float64) {
+	sp := p.current()
+	if sp == nil {
+		p.MoveTo(c1x, c1y)
+		sp = p.current()
+	}
+	p0 := sp.points[len(sp.points)-1]
+	dd := math.Max(math.Hypot(p0.X-2*c1x+c2x, p0.Y-2*c1y+c2y), math.Hypot(c1x-2*c2x+x, c1y-2*c2y+y))
+	n := curveSegments(3 * dd / (4 * flattenTolerance))
+	for i := 1; i <= n; i++ {
+		t := float64(i) / float64(n)
+		mt := 1 - t
+		sp.points = append(sp.points, PointF{
+			X: mt*mt*mt*p0.X + 3*mt*mt*t*c1x + 3*mt*t*t*c2x + t*t*t*x,
+			Y: mt*mt*mt*p0.Y + 3*mt*mt*t*c1y + 3*mt*t*t*c2y + t*t*t*y,
+		})
+	}
+}
+
+// curveSegments returns the number of line segments needed for a curve, given the square of the count needed for the tolerance
+func curveSegments(squared float64) int {
+	n := int(math.Ceil(math.Sqrt(squared)))
+	return min(max(n, 1), 1000)
+}
+
+// Close adds a straight line back to the start of the current subpath, and ends it
+func (p *Path) Close() {
+	if len(p.subpaths) == 0 {
+		return
+	}
+	p.subpaths[len(p.subpaths)-1].closed = true
+}
+
+// edge is a non-horizontal line segment of a path, oriented so that y1 < y2
+type edge struct {
+	x1, y1, x2, y2 float64
+	winding        int // +1 if the path travels down this edge, -1 if up
+	span           int // While tessellating, the index of the open span this edge is the left of
+}
+
+func (e *edge) xAt(y float64) float64 {
+	return e.x1 + (e.x2-e.x1)*(y-e.y1)/(e.y2-e.y1)
+}
+
+// edges returns the edges of every subpath, implicitly closing them as filling requires
+func (p *Path) edges() []edge {
+	var retval []edge
+	for _, sp := range p.subpaths {
+		for i := range sp.points {
+			a := sp.points[i]
+			b := sp.points[(i+1)%len(sp.points)]
+			switch {
+			case a.Y < b.Y:
+				retval = append(retval, edge{x1: a.X, y1: a.Y, x2: b.X, y2: b.Y, winding: 1})
+			case a.Y > b.Y:
+				retval = append(retval, edge{x1: b.X, y1: b.Y, x2: a.X, y2: a.Y, winding: -1})
+			}
+		}
+	}
+	return retval
+}
+
+// bandEdge is an edge crossing a band, with its x coordinates at the top and bottom of the band, and the
+// coordinate it is ordered by
+type bandEdge struct {
+	e          *edge
+	xt, xb, xs float64
+}
+
+// sortBandEdges sorts edges by xs, then xb. The edges are kept in their order from the band above, which
+// rarely changes, so an insertion sort is close to linear.
+func sortBandEdges(edges []bandEdge) {
+	for i := 1; i < len(edges); i++ {
+		for j := i; j > 0; j-- {
+			a, b := &edges[j-1], &edges[j]
+			if a.xs < b.xs || (a.xs == b.xs && a.xb <= b.xb) {
+				break
+			}
+			*a, *b = *b, *a
+		}
+	}
+}
+
+// span is a filled area between two edges, which stays a single trapezoid for as long as the same
+// two edges bound it
+type span struct {
+	left, right *edge
+	top         float64
+}
+
+// tessellate splits the area filled by path under rule into trapezoids. The area is divided into horizontal
+// bands at every vertex and edge intersection, so within a band no edges cross and each filled span is a trapezoid.
+// A span bounded by the same edges in consecutive bands is extended rather than split, so long paths produce
+// a trapezoid per edge section rather than one per band.
+func (p *Path) tessellate(rule FillRule) []PixmanTrapezoid {
+	edges := p.edges()
+	if len(edges) == 0 {
+		return nil
+	}
+	sort.Slice(edges, func(i, j int) bool {
+		return edges[i].y1 < edges[j].y1
+	})
+	ys := make([]float64, 0, 2*len(edges))
+	for _, e := range edges {
+		ys = append(ys, e.y1, e.y2)
+	}
+	sort.Float64s(ys)
+
+	var traps []PixmanTrapezoid
+	var active []*edge
+	next := 0
+	var bandEdges []bandEdge
+	var open, filled []span
+	for k := 0; k+1 < len(ys); k++ {
+		top := ys[k]
+		for top < ys[k+1] {
+			bottom := ys[k+1]
+
+			// Update the edges spanning this band, keeping the remaining edges in their order from the band above
+			n := 0
+			for _, e := range active {
+				if e.y2 > top {
+					active[n] = e
+					n++
+				}
+			}
+			active = active[:n]
+			for next < len(edges) && edges[next].y1 <= top {
+				active = append(active, &edges[next])
+				next++
+			}
+
+			bandEdges = bandEdges[:0]
+			for _, e := range active {
+				bandEdges = append(bandEdges, bandEdge{e: e, xt: e.xAt(top), xb: e.xAt(bottom)})
+			}
+
+			// Edges that swap order between the top and bottom of the band cross inside it, so end the band at the
+			// first crossing. Crossings just below the top are ignored, so order the edges just below it; until the
+			// first crossing they keep that order, so it is between two edges that are adjacent in it.
+			if probe := top + 1e-6; probe < bottom {
+				for i := range bandEdges {
+					bandEdges[i].xs = bandEdges[i].e.xAt(probe)
+				}
+				sortBandEdges(bandEdges)
+				for i := 0; i+1 < len(bandEdges); i++ {
+					a, b := bandEdges[i], bandEdges[i+1]
+					if a.xb <= b.xb {
+						continue
+					}
+					t := (b.xt - a.xt) / ((b.xt - a.xt) - (b.xb - a.xb))
+					if y := top + t*(ys[k+1]-top); y > probe && y < bottom {
+						bottom = y
+					}
+				}
+			}
+
+			// With no crossings inside the band, the edges are ordered by their midpoints
+			for i := range bandEdges {
+				bandEdges[i].xb = bandEdges[i].e.xAt(bottom)
+				bandEdges[i].xs = bandEdges[i].xt + bandEdges[i].xb
+			}
+			sortBandEdges(bandEdges)
+			for i, be := range bandEdges {
+				active[i] = be.e
+			}
+
+			// Carry on the spans that continue from the band above, and end the rest there
+			filled = bandSpans(filled[:0], top, bandEdges, rule)
+			for i, s := range filled {
+				if j := s.left.span; j < len(open) && open[j].left == s.left && open[j].right == s.right {
+					filled[i].top = open[j].top
+					open[j].left = nil
+				}
+			}
+			traps = appendSpanTrapezoids(traps, open, top)
+			open, filled = filled, open
+			for i, s := range open {
+				s.left.span = i
+			}
+			top = bottom
+		}
+	}
+	return appendSpanTrapezoids(traps, open, ys[len(ys)-1])
+}
+
+// bandSpans appends the filled spans between the sorted edges crossing a band to spans, each starting at top
+func bandSpans(spans []span, top float64, edges []bandEdge, rule FillRule) []span {
+	winding := 0
+	var left *edge
+	for _, be := range edges {
+		wasInside := insideFill(winding, rule)
+		winding += be.e.winding
+		isInside := insideFill(winding, rule)
+		switch {
+		case !wasInside && isInside:
+			left = be.e
+		case wasInside && !isInside:
+			spans = append(spans, span{left: left, right: be.e, top: top})
+		}
+	}
+	return spans
+}
+
+// appendSpanTrapezoids adds a trapezoid from the top of each of spans down to bottom, skipping spans that
+// have been carried on into the next band
+func appendSpanTrapezoids(traps []PixmanTrapezoid, spans []span, bottom float64) []PixmanTrapezoid {
+	fixedBottom := FixedFromFloat(bottom)
+	for _, s := range spans {
+		if s.left == nil {
+			continue
+		}
+		fixedTop := FixedFromFloat(s.top)
+		if fixedTop >= fixedBottom {
+			continue
+		}
+		traps = append(traps, PixmanTrapezoid{
+			Top:    fixedTop,
+			Bottom: fixedBottom,
+			Left:   LineFixedFromFloat(s.left.x1, s.left.y1, s.left.x2, s.left.y2),
+			Right:  LineFixedFromFloat(s.right.x1, s.right.y1, s.right.x2, s.right.y2),
+		})
+	}
+	return traps
+}
+
+func insideFill(winding int, rule FillRule) bool {
+	if rule == FillRuleEvenOdd {
+		return winding%2 != 0
+	}
+	return winding != 0
+}
+
+// FillPath fills the area enclosed by path in dst with src, according to rule.
+// Coordinates are in dst space, and src is aligned with dst.
+func FillPath(dst *Image, path *Path, src *Image, rule FillRule) {
+	CompositeTrapezoids(PIXMAN_OP_OVER, src, dst, PIXMAN_a8, path.tessellate(rule))
+}
+
+// boundedOperator reports whether pixman limits op to the area covered by a trapezoid mask. The other
+// operators change the destination even where the source is transparent, so pixman applies them to the
+// whole destination.
+func boundedOperator(op PixmanOperation) bool {
+	switch op {
+	case PIXMAN_OP_DST, PIXMAN_OP_OVER, PIXMAN_OP_OVER_REVERSE, PIXMAN_OP_OUT_REVERSE,
+		PIXMAN_OP_ATOP, PIXMAN_OP_XOR, PIXMAN_OP_ADD:
+		return true
+	default:
+		return false
+	}
+}
+
+// fillPath fills path like FillPath, but using op. An unbounded operator is applied to a copy of the area under
+// the path, which is then blended back into dst through the path's coverage, leaving the rest of dst untouched.
+func fillPath(op PixmanOperation, dst *Image, path *Path, src *Image, rule FillRule) error {
+	traps := path.tessellate(rule)
+	if boundedOperator(op) {
+		CompositeTrapezoids(op, src, dst, PIXMAN_a8, traps)
+		return nil
+	}
+	box := path.bounds().Intersect(dst.Bounds())
+	if len(traps) == 0 || box.Empty() {
+		return nil
+	}
+	size := box.Size()
+	mask, err := imageCreate(PIXMAN_a8, size.X, size.Y)
+	if err != nil {
+		return err
+	}
+	for _, trap := range traps {
+		if err := mask.RasterizeTrapezoid(trap, -box.Min.X, -box.Min.Y); err != nil {
+			return err
+		}
+	}
+	result, err := imageCreate(ImageGetFormat(dst.pixman), size.X, size.Y)
+	if err != nil {
+		return err
+	}
+	result.CompositeOp(PIXMAN_OP_SRC, dst, nil, box.Min, image.Point{}, image.Point{}, size)
+	result.CompositeOp(op, src, nil, box.Min, image.Point{}, image.Point{}, size)
+	// dst = dst * (1 - coverage) + result * coverage
+	dst.CompositeOp(PIXMAN_OP_OUT_REVERSE, mask, nil, image.Point{}, image.Point{}, box.Min, size)
+	dst.CompositeOp(PIXMAN_OP_ADD, result, mask, image.Point{}, image.Point{}, box.Min, size)
+	return nil
+}
+
+// bounds returns the smallest integer rectangle containing every point of the path
+func (p *Path) bounds() image.Rectangle {
+	minX, minY := math.Inf(1), math.Inf(1)
+	maxX, maxY := math.Inf(-1), math.Inf(-1)
+	for _, sp := range p.subpaths {
+		for _, pt := range sp.points {
+			minX, minY = min(minX, pt.X), min(minY, pt.Y)
+			maxX, maxY = max(maxX, pt.X), max(maxY, pt.Y)
+		}
+	}
+	if minX > maxX || minY > maxY {
+		return image.Rectangle{}
+	}
+	return image.Rect(int(math.Floor(minX)), int(math.Floor(minY)), int(math.Ceil(maxX)), int(math.Ceil(maxY)))
+}
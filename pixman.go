@@ -1,9 +1,11 @@
 package pixman
 
 import (
+	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
+	"image/draw"
 	"os"
 	"runtime"
 	"unsafe"
@@ -17,24 +19,93 @@ var (
 	// These must match the C function signatures
 	ImageCreateBits      func(format PixmanFormatCode, width int, height int, bits *uint32, rowstride int) *PixmanImage
 	ImageCreateSolidFill func(color *PixmanColor) *PixmanImage
-	ImageGetFormat       func(image *PixmanImage) PixmanFormatCode
-	ImageGetWidth        func(image *PixmanImage) int32
-	ImageGetHeight       func(image *PixmanImage) int32
-	ImageGetStride       func(image *PixmanImage) int32
-	ImageGetDepth        func(image *PixmanImage) int32
-	ImageGetData         func(image *PixmanImage) *uint32
-	ImageComposite32     func(op PixmanOperation, src *PixmanImage, mask *PixmanImage, dest *PixmanImage, src_x, src_y, mask_x, mask_y, dest_x, dest_y int32, width, height int32)
-	Fill                 func(bits *uint32, stride int, bpp int, x int, y int, width int, height int, xor uint32) int
-	ImageUnref           func(image *PixmanImage) int
+
+	ImageCreateLinearGradient  func(p1, p2 *PixmanPointFixed, stops *PixmanGradientStop, nStops int32) *PixmanImage
+	ImageCreateRadialGradient  func(inner, outer *PixmanPointFixed, innerRadius, outerRadius PixmanFixed, stops *PixmanGradientStop, nStops int32) *PixmanImage
+	ImageCreateConicalGradient func(center *PixmanPointFixed, angle PixmanFixed, stops *PixmanGradientStop, nStops int32) *PixmanImage
+
+	ImageGetFormat         func(image *PixmanImage) PixmanFormatCode
+	ImageGetWidth          func(image *PixmanImage) int32
+	ImageGetHeight         func(image *PixmanImage) int32
+	ImageGetStride         func(image *PixmanImage) int32
+	ImageGetDepth          func(image *PixmanImage) int32
+	ImageGetData           func(image *PixmanImage) *uint32
+	ImageComposite32       func(op PixmanOperation, src *PixmanImage, mask *PixmanImage, dest *PixmanImage, src_x, src_y, mask_x, mask_y, dest_x, dest_y int32, width, height int32)
+	Fill                   func(bits *uint32, stride int, bpp int, x int, y int, width int, height int, xor uint32) int
+	ImageUnref             func(image *PixmanImage) int
+	ImageSetTransform      func(image *PixmanImage, transform *Transform) bool
+	ImageGetTransform      func(image *PixmanImage) *Transform
+	ImageSetFilter         func(image *PixmanImage, filter PixmanFilter, params *PixmanFixed, nParams int32) bool
+	ImageSetRepeat         func(image *PixmanImage, repeat PixmanRepeat)
+	ImageSetClipRegion32   func(image *PixmanImage, region *PixmanRegion32) bool
+	ImageSetSourceClipping func(image *PixmanImage, sourceClipping bool)
+	ImageSetHasClientClip  func(image *PixmanImage, clientClip bool)
+	ImageSetComponentAlpha func(image *PixmanImage, componentAlpha bool)
+	ImageGetComponentAlpha func(image *PixmanImage) bool
+	ImageSetAlphaMap       func(image *PixmanImage, alphaMap *PixmanImage, x, y int16)
+	ImageFillBoxes         func(op PixmanOperation, dest *PixmanImage, color *PixmanColor, nBoxes int32, boxes *PixmanBox32) bool
+
+	Region32Init              func(region *PixmanRegion32)
+	Region32InitRects         func(region *PixmanRegion32, boxes *PixmanBox32, count int32) bool
+	Region32Fini              func(region *PixmanRegion32)
+	Region32Union             func(newReg, reg1, reg2 *PixmanRegion32) bool
+	Region32Intersect         func(newReg, reg1, reg2 *PixmanRegion32) bool
+	Region32Subtract          func(regD, regM, regS *PixmanRegion32) bool
+	Region32Inverse           func(newReg, reg1 *PixmanRegion32, invRect *PixmanBox32) bool
+	Region32Translate         func(region *PixmanRegion32, x, y int32)
+	Region32ContainsPoint     func(region *PixmanRegion32, x, y int32, box *PixmanBox32) bool
+	Region32ContainsRectangle func(region *PixmanRegion32, prect *PixmanBox32) PixmanRegionOverlap
+	Region32Extents           func(region *PixmanRegion32) *PixmanBox32
+	Region32Rectangles        func(region *PixmanRegion32, nRects *int32) *PixmanBox32
+	Region32Equal             func(region1, region2 *PixmanRegion32) bool
+	Region32NotEmpty          func(region *PixmanRegion32) bool
+
+	AddTrapezoids      func(image *PixmanImage, xOff int16, yOff int32, nTraps int32, traps *PixmanTrapezoid)
+	RasterizeTrapezoid func(image *PixmanImage, trap *PixmanTrapezoid, xOff, yOff int32)
+	AddTriangles       func(image *PixmanImage, xOff, yOff int32, nTris int32, tris *PixmanTriangle)
+
+	GlyphCacheCreate   func() *PixmanGlyphCache
+	GlyphCacheDestroy  func(cache *PixmanGlyphCache)
+	GlyphCacheFreeze   func(cache *PixmanGlyphCache)
+	GlyphCacheThaw     func(cache *PixmanGlyphCache)
+	GlyphCacheLookup   func(cache *PixmanGlyphCache, fontKey, glyphKey uintptr) uintptr
+	GlyphCacheInsert   func(cache *PixmanGlyphCache, fontKey, glyphKey uintptr, originX, originY int32, glyphImage *PixmanImage) uintptr
+	GlyphCacheRemove   func(cache *PixmanGlyphCache, fontKey, glyphKey uintptr)
+	GlyphGetExtents    func(cache *PixmanGlyphCache, nGlyphs int32, glyphs *PixmanGlyph, extents *PixmanBox32)
+	GlyphGetMaskFormat func(cache *PixmanGlyphCache, nGlyphs int32, glyphs *PixmanGlyph) PixmanFormatCode
+
+	TransformInitIdentity  func(transform *Transform)
+	TransformInitScale     func(transform *Transform, sx, sy PixmanFixed)
+	TransformInitRotate    func(transform *Transform, cos, sin PixmanFixed)
+	TransformInitTranslate func(transform *Transform, tx, ty PixmanFixed)
+	TransformMultiply      func(dst *Transform, l *Transform, r *Transform) bool
+	TransformInvert        func(dst *Transform, src *Transform) bool
+
+	// Exposed to Go as CompositeTrapezoids, CompositeTriangles and CompositeGlyphs, which pick the offsets
+	pixmanCompositeTrapezoids func(op PixmanOperation, src, dst *PixmanImage, maskFormat PixmanFormatCode, xSrc, ySrc, xDst, yDst int32, nTraps int32, traps *PixmanTrapezoid)
+	pixmanCompositeTriangles  func(op PixmanOperation, src, dst *PixmanImage, maskFormat PixmanFormatCode, xSrc, ySrc, xDst, yDst int32, nTris int32, tris *PixmanTriangle)
+	pixmanCompositeGlyphs     func(op PixmanOperation, src, dst *PixmanImage, maskFormat PixmanFormatCode, srcX, srcY, maskX, maskY, destX, destY, width, height int32, cache *PixmanGlyphCache, nGlyphs int32, glyphs *PixmanGlyph)
 )
 
+// Pixman formats are defined in terms of native-endian pixel values, so the format matching
+// the R, G, B, A byte order of image.RGBA depends on the host byte order.
+var formatRGBA, formatRGBX = func() (PixmanFormatCode, PixmanFormatCode) {
+	if binary.NativeEndian.Uint16([]byte{1, 0}) == 1 {
+		return PIXMAN_a8b8g8r8, PIXMAN_x8b8g8r8
+	}
+	return PIXMAN_r8g8b8a8, PIXMAN_r8g8b8x8
+}()
+
 type Image struct {
-	rawData []byte
-	pixman  *PixmanImage
+	rawData  []byte
+	pixman   *PixmanImage
+	alphaMap *Image // Keeps the Go memory behind an alpha map alive while pixman uses it
 }
 
 type PixmanImage struct{}
 
+type PixmanGlyphCache struct{}
+
 func findPixmanLibrary() string {
 	var dirs []string
 	libraryName := "libpixman-1.so.0"
@@ -69,6 +140,9 @@ func init() {
 
 	purego.RegisterLibFunc(&ImageCreateBits, pixmanLib, "pixman_image_create_bits")
 	purego.RegisterLibFunc(&ImageCreateSolidFill, pixmanLib, "pixman_image_create_solid_fill")
+	purego.RegisterLibFunc(&ImageCreateLinearGradient, pixmanLib, "pixman_image_create_linear_gradient")
+	purego.RegisterLibFunc(&ImageCreateRadialGradient, pixmanLib, "pixman_image_create_radial_gradient")
+	purego.RegisterLibFunc(&ImageCreateConicalGradient, pixmanLib, "pixman_image_create_conical_gradient")
 	purego.RegisterLibFunc(&ImageGetFormat, pixmanLib, "pixman_image_get_format")
 	purego.RegisterLibFunc(&ImageGetWidth, pixmanLib, "pixman_image_get_width")
 	purego.RegisterLibFunc(&ImageGetHeight, pixmanLib, "pixman_image_get_height")
@@ -78,8 +152,62 @@ func init() {
 	purego.RegisterLibFunc(&ImageComposite32, pixmanLib, "pixman_image_composite32")
 	purego.RegisterLibFunc(&ImageUnref, pixmanLib, "pixman_image_unref")
 	purego.RegisterLibFunc(&Fill, pixmanLib, "pixman_fill")
+	purego.RegisterLibFunc(&ImageSetTransform, pixmanLib, "pixman_image_set_transform")
+	purego.RegisterLibFunc(&ImageGetTransform, pixmanLib, "pixman_image_get_transform")
+	purego.RegisterLibFunc(&ImageSetFilter, pixmanLib, "pixman_image_set_filter")
+	purego.RegisterLibFunc(&ImageSetRepeat, pixmanLib, "pixman_image_set_repeat")
+	purego.RegisterLibFunc(&ImageSetClipRegion32, pixmanLib, "pixman_image_set_clip_region32")
+	purego.RegisterLibFunc(&ImageSetSourceClipping, pixmanLib, "pixman_image_set_source_clipping")
+	purego.RegisterLibFunc(&ImageSetHasClientClip, pixmanLib, "pixman_image_set_has_client_clip")
+	purego.RegisterLibFunc(&ImageSetComponentAlpha, pixmanLib, "pixman_image_set_component_alpha")
+	purego.RegisterLibFunc(&ImageGetComponentAlpha, pixmanLib, "pixman_image_get_component_alpha")
+	purego.RegisterLibFunc(&ImageSetAlphaMap, pixmanLib, "pixman_image_set_alpha_map")
+	purego.RegisterLibFunc(&ImageFillBoxes, pixmanLib, "pixman_image_fill_boxes")
+
+	purego.RegisterLibFunc(&Region32Init, pixmanLib, "pixman_region32_init")
+	purego.RegisterLibFunc(&Region32InitRects, pixmanLib, "pixman_region32_init_rects")
+	purego.RegisterLibFunc(&Region32Fini, pixmanLib, "pixman_region32_fini")
+	purego.RegisterLibFunc(&Region32Union, pixmanLib, "pixman_region32_union")
+	purego.RegisterLibFunc(&Region32Intersect, pixmanLib, "pixman_region32_intersect")
+	purego.RegisterLibFunc(&Region32Subtract, pixmanLib, "pixman_region32_subtract")
+	purego.RegisterLibFunc(&Region32Inverse, pixmanLib, "pixman_region32_inverse")
+	purego.RegisterLibFunc(&Region32Translate, pixmanLib, "pixman_region32_translate")
+	purego.RegisterLibFunc(&Region32ContainsPoint, pixmanLib, "pixman_region32_contains_point")
+	purego.RegisterLibFunc(&Region32ContainsRectangle, pixmanLib, "pixman_region32_contains_rectangle")
+	purego.RegisterLibFunc(&Region32Extents, pixmanLib, "pixman_region32_extents")
+	purego.RegisterLibFunc(&Region32Rectangles, pixmanLib, "pixman_region32_rectangles")
+	purego.RegisterLibFunc(&Region32Equal, pixmanLib, "pixman_region32_equal")
+	purego.RegisterLibFunc(&Region32NotEmpty, pixmanLib, "pixman_region32_not_empty")
+
+	purego.RegisterLibFunc(&AddTrapezoids, pixmanLib, "pixman_add_trapezoids")
+	purego.RegisterLibFunc(&RasterizeTrapezoid, pixmanLib, "pixman_rasterize_trapezoid")
+	purego.RegisterLibFunc(&pixmanCompositeTrapezoids, pixmanLib, "pixman_composite_trapezoids")
+	purego.RegisterLibFunc(&AddTriangles, pixmanLib, "pixman_add_triangles")
+	purego.RegisterLibFunc(&pixmanCompositeTriangles, pixmanLib, "pixman_composite_triangles")
+
+	purego.RegisterLibFunc(&GlyphCacheCreate, pixmanLib, "pixman_glyph_cache_create")
+	purego.RegisterLibFunc(&GlyphCacheDestroy, pixmanLib, "pixman_glyph_cache_destroy")
+	purego.RegisterLibFunc(&GlyphCacheFreeze, pixmanLib, "pixman_glyph_cache_freeze")
+	purego.RegisterLibFunc(&GlyphCacheThaw, pixmanLib, "pixman_glyph_cache_thaw")
+	purego.RegisterLibFunc(&GlyphCacheLookup, pixmanLib, "pixman_glyph_cache_lookup")
+	purego.RegisterLibFunc(&GlyphCacheInsert, pixmanLib, "pixman_glyph_cache_insert")
+	purego.RegisterLibFunc(&GlyphCacheRemove, pixmanLib, "pixman_glyph_cache_remove")
+	purego.RegisterLibFunc(&GlyphGetExtents, pixmanLib, "pixman_glyph_get_extents")
+	purego.RegisterLibFunc(&GlyphGetMaskFormat, pixmanLib, "pixman_glyph_get_mask_format")
+	purego.RegisterLibFunc(&pixmanCompositeGlyphs, pixmanLib, "pixman_composite_glyphs")
+
+	purego.RegisterLibFunc(&TransformInitIdentity, pixmanLib, "pixman_transform_init_identity")
+	purego.RegisterLibFunc(&TransformInitScale, pixmanLib, "pixman_transform_init_scale")
+	purego.RegisterLibFunc(&TransformInitRotate, pixmanLib, "pixman_transform_init_rotate")
+	purego.RegisterLibFunc(&TransformInitTranslate, pixmanLib, "pixman_transform_init_translate")
+	purego.RegisterLibFunc(&TransformMultiply, pixmanLib, "pixman_transform_multiply")
+	purego.RegisterLibFunc(&TransformInvert, pixmanLib, "pixman_transform_invert")
+
 }
 
+// ImageFromImage creates an image that shares the pixels of an *image.RGBA, so drawing to either is
+// visible in both. Pixman formats are premultiplied, so an *image.NRGBA is converted to a premultiplied
+// copy instead, and drawing to the result leaves the original unchanged.
 func ImageFromImage(img image.Image) (*Image, error) {
 	// We don't do subimages yet
 	if img.Bounds().Min.X != 0 || img.Bounds().Min.Y != 0 {
@@ -91,13 +219,15 @@ func ImageFromImage(img image.Image) (*Image, error) {
 	var bits *uint32
 	switch t := img.(type) {
 	case *image.RGBA:
-		format = PIXMAN_r8g8b8a8
+		format = formatRGBA
 		stride = t.Stride
 		bits = (*uint32)(unsafe.Pointer(&t.Pix[0]))
 	case *image.NRGBA:
-		format = PIXMAN_r8g8b8a8
-		stride = t.Stride
-		bits = (*uint32)(unsafe.Pointer(&t.Pix[0]))
+		premultiplied := image.NewRGBA(bounds)
+		draw.Draw(premultiplied, bounds, t, bounds.Min, draw.Src)
+		format = formatRGBA
+		stride = premultiplied.Stride
+		bits = (*uint32)(unsafe.Pointer(&premultiplied.Pix[0]))
 	default:
 		return nil, fmt.Errorf("unsupported image format %T", img)
 	}
@@ -149,16 +279,39 @@ func ImageFromBits(format PixmanFormatCode, width, height int, bits []byte, stri
 	return retval, nil
 }
 
-func ImageSolid(col color.Color) (*Image, error) {
+// imageCreate allocates a new, cleared image whose pixel storage is owned by pixman
+func imageCreate(format PixmanFormatCode, width, height int) (*Image, error) {
+	if width <= 0 || height <= 0 {
+		return nil, fmt.Errorf("invalid image dimensions: width=%d, height=%d", width, height)
+	}
+	retval := &Image{}
+	retval.pixman = ImageCreateBits(format, width, height, nil, 0)
+	if retval.pixman == nil {
+		return nil, fmt.Errorf("failed to create Pixman image with format %s", format)
+	}
+	stride := int(ImageGetStride(retval.pixman))
+	retval.rawData = unsafe.Slice((*uint8)(unsafe.Pointer(ImageGetData(retval.pixman))), stride*height)
+	runtime.AddCleanup(retval, func(raw *PixmanImage) {
+		ImageUnref(raw)
+	}, retval.pixman)
+	return retval, nil
+}
+
+// pixmanColor converts col to a premultiplied PixmanColor
+func pixmanColor(col color.Color) PixmanColor {
 	r, g, b, a := col.RGBA()
-	pixCol := &PixmanColor{
+	return PixmanColor{
 		Red:   uint16(r),
 		Green: uint16(g),
 		Blue:  uint16(b),
 		Alpha: uint16(a),
 	}
+}
+
+func ImageSolid(col color.Color) (*Image, error) {
+	pixCol := pixmanColor(col)
 	retval := &Image{}
-	retval.pixman = ImageCreateSolidFill(pixCol)
+	retval.pixman = ImageCreateSolidFill(&pixCol)
 	if retval.pixman == nil {
 		return nil, fmt.Errorf("failed to create Pixman solid fill image")
 	}
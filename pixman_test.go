@@ -1,12 +1,16 @@
 package pixman
 
 import (
+	"bytes"
+	"encoding/binary"
 	"fmt"
 	"image"
 	"image/color"
 	"image/draw"
 	"image/png"
+	"math"
 	"os"
+	"strings"
 	"testing"
 )
 
@@ -35,6 +39,25 @@ func compareSubImage(img1, img2 image.Image, bounds image.Rectangle, delta uint3
 	return nil
 }
 
+// assertCoverage checks that the pixels of img within covered are col, and every other pixel is transparent
+func assertCoverage(t *testing.T, img image.Image, covered []image.Rectangle, col color.Color) {
+	t.Helper()
+	bounds := img.Bounds()
+	for y := bounds.Min.Y; y < bounds.Max.Y; y++ {
+		for x := bounds.Min.X; x < bounds.Max.X; x++ {
+			expected := color.Color(color.Transparent)
+			for _, r := range covered {
+				if image.Pt(x, y).In(r) {
+					expected = col
+				}
+			}
+			if !colorMatch(img.At(x, y), expected, 0) {
+				t.Fatalf("Pixel at (%d,%d) is %v, expected %v", x, y, img.At(x, y), expected)
+			}
+		}
+	}
+}
+
 func buildRGB565(img image.Image) ([]byte, error) {
 	bounds := img.Bounds()
 	if bounds.Min.X != 0 || bounds.Min.Y != 0 {
@@ -130,6 +153,39 @@ func BenchmarkPixmanBlit(b *testing.B) {
 	}
 }
 
+// A closed trace with thousands of segments, like an oscilloscope plot filled down to its baseline
+func BenchmarkTessellate(b *testing.B) {
+	var path Path
+	path.MoveTo(0, 200)
+	for i := range 4000 {
+		path.LineTo(float64(i)/4, 100+80*math.Sin(float64(i)*0.05))
+	}
+	path.LineTo(1000, 200)
+	path.Close()
+	for i := 0; i < b.N; i++ {
+		path.tessellate(FillRuleNonZero)
+	}
+}
+
+// Thousands of segments per frame, as for an oscilloscope trace
+func BenchmarkDrawPolyline(b *testing.B) {
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 1024, 256)))
+	if err != nil {
+		b.Fatalf("failed to create Pixman image: %v", err)
+	}
+	src, err := ImageSolid(color.RGBA{G: 255, A: 255})
+	if err != nil {
+		b.Fatalf("failed to create solid image: %v", err)
+	}
+	points := make([]PointF, 4000)
+	for i := range points {
+		points[i] = PointF{X: float64(i) / 4, Y: 128 + 100*math.Sin(float64(i)*0.05)}
+	}
+	for i := 0; i < b.N; i++ {
+		DrawPolyline(dest, points, 1.5, src)
+	}
+}
+
 func TestImageFill(t *testing.T) {
 	img := image.NewRGBA(image.Rect(0, 0, 320, 240))
 	col := color.RGBA{R: 255, G: 0, B: 0, A: 255}
@@ -147,6 +203,51 @@ func TestImageFill(t *testing.T) {
 	}
 }
 
+// A rectangle reaching past the image must be clipped to it, leaving memory beyond the image alone
+func TestImageFillOutOfBounds(t *testing.T) {
+	// 2x2 pixels followed by a guard row
+	raw := make([]byte, 8*3)
+	pixmanImg, err := ImageFromBits(formatRGBA, 2, 2, raw, 8)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	col := color.RGBA{R: 255, G: 0, B: 0, A: 255}
+	pixmanImg.Fill(image.Rect(-10, -10, 100, 100), col)
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: col}, pixmanImg.Bounds(), 0); err != nil {
+		t.Errorf("Oversized fill did not cover the image: %v", err)
+	}
+	if !bytes.Equal(raw[16:], make([]byte, 8)) {
+		t.Errorf("Fill wrote past the image: %v", raw[16:])
+	}
+	// A rectangle entirely outside the image is a no-op
+	pixmanImg.Fill(image.Rect(5, 5, 10, 10), color.White)
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: col}, pixmanImg.Bounds(), 0); err != nil {
+		t.Errorf("Fill outside the image changed it: %v", err)
+	}
+}
+
+func TestImageFillBoxes(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 16, 16))
+	pixmanImg, err := ImageFromImage(img)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	red := color.RGBA{R: 255, A: 255}
+	rects := []image.Rectangle{image.Rect(1, 1, 4, 4), image.Rect(10, 12, 20, 20), image.Rect(30, 30, 40, 40)}
+	if err := pixmanImg.FillBoxes(PIXMAN_OP_SRC, rects, red); err != nil {
+		t.Fatalf("failed to fill boxes: %v", err)
+	}
+	assertCoverage(t, pixmanImg, []image.Rectangle{image.Rect(1, 1, 4, 4), image.Rect(10, 12, 16, 16)}, red)
+
+	// Boxes outside the image, or none at all, have nothing to fill
+	if err := pixmanImg.FillBoxes(PIXMAN_OP_SRC, rects[2:], red); err != nil {
+		t.Errorf("FillBoxes outside the image failed: %v", err)
+	}
+	if err := pixmanImg.FillBoxes(PIXMAN_OP_SRC, nil, red); err != nil {
+		t.Errorf("FillBoxes with no boxes failed: %v", err)
+	}
+}
+
 func TestImageBlit(t *testing.T) {
 	img, err := loadFile("testdata/pg-coral.png")
 	if err != nil {
@@ -169,6 +270,58 @@ func TestImageBlit(t *testing.T) {
 	}
 }
 
+// Pixman formats describe native-endian pixel values, so an image.RGBA must be mapped to the
+// format whose channels land in R, G, B, A byte order on this host
+func TestImageFromImageByteOrder(t *testing.T) {
+	img := image.NewRGBA(image.Rect(0, 0, 1, 1))
+	img.SetRGBA(0, 0, color.RGBA{R: 200, G: 100, B: 50, A: 255})
+	src, err := ImageFromImage(img)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+
+	// Pixman's own idea of the pixel, independent of the Go-side codec
+	raw := make([]byte, 4)
+	dest, err := ImageFromBits(PIXMAN_a8r8g8b8, 1, 1, raw, 4)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Composite(src, src.Bounds(), image.Point{})
+	if got := binary.NativeEndian.Uint32(raw); got != 0xffc86432 {
+		t.Errorf("Composited pixel is %#08x, expected 0xffc86432", got)
+	}
+
+	// And a colour pixman writes must come back out in image.RGBA byte order
+	src.Fill(src.Bounds(), color.RGBA{R: 10, G: 20, B: 30, A: 255})
+	if !bytes.Equal(img.Pix, []byte{10, 20, 30, 255}) {
+		t.Errorf("Filled pixel bytes are %v, expected [10 20 30 255]", img.Pix)
+	}
+}
+
+// Pixman formats are premultiplied, so an image.NRGBA must be premultiplied before pixman composites it
+func TestImageFromNRGBA(t *testing.T) {
+	img := image.NewNRGBA(image.Rect(0, 0, 1, 1))
+	img.SetNRGBA(0, 0, color.NRGBA{R: 200, G: 100, B: 50, A: 128})
+	src, err := ImageFromImage(img)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if expected := color.RGBAModel.Convert(img.At(0, 0)); !colorMatch(src.At(0, 0), expected, 1) {
+		t.Errorf("NRGBA pixel reads back as %v, expected %v", src.At(0, 0), expected)
+	}
+
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 1, 1)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Fill(dest.Bounds(), color.Black)
+	dest.CompositeOp(PIXMAN_OP_OVER, src, nil, image.Point{}, image.Point{}, image.Point{}, image.Pt(1, 1))
+	expected := color.RGBA{R: 100, G: 50, B: 25, A: 255}
+	if !colorMatch(dest.At(0, 0), expected, 1) {
+		t.Errorf("Composited NRGBA pixel is %v, expected %v", dest.At(0, 0), expected)
+	}
+}
+
 // Load various images, manually build a RGB565 representation, and compare it to the original image.
 func TestRGB565(t *testing.T) {
 	images := []string{"testdata/red.png", "testdata/blue.png", "testdata/green.png", "testdata/pg-coral.png"}
@@ -193,3 +346,1898 @@ func TestRGB565(t *testing.T) {
 		}
 	}
 }
+
+func TestTransformInvert(t *testing.T) {
+	scale := TransformScale(2, 4)
+	inverse, err := scale.Invert()
+	if err != nil {
+		t.Fatalf("failed to invert transform: %v", err)
+	}
+	if expected := TransformScale(0.5, 0.25); inverse != expected {
+		t.Errorf("inverse of scale did not match: got %v, expected %v", inverse.Matrix, expected.Matrix)
+	}
+	product, err := scale.Multiply(inverse)
+	if err != nil {
+		t.Fatalf("failed to multiply transforms: %v", err)
+	}
+	if product != TransformIdentity() {
+		t.Errorf("transform multiplied by its inverse is not the identity: %v", product.Matrix)
+	}
+	if _, err := TransformScale(0, 0).Invert(); err == nil {
+		t.Errorf("expected an error inverting a singular transform")
+	}
+}
+
+func TestImageTransform(t *testing.T) {
+	img, err := loadFile("testdata/pg-coral.png")
+	if err != nil {
+		t.Fatalf("failed to load image: %v", err)
+	}
+	srcImage, err := ImageFromImage(img)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	translate := TransformTranslate(100, 50)
+	if err := srcImage.SetTransform(&translate); err != nil {
+		t.Fatalf("failed to set transform: %v", err)
+	}
+	if srcImage.Transform() != translate {
+		t.Errorf("transform was not stored on the image")
+	}
+
+	dest := image.NewRGBA(image.Rect(0, 0, 200, 200))
+	pixmanImg, err := ImageFromImage(dest)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	pixmanImg.Composite(srcImage, dest.Bounds(), image.Point{X: 0, Y: 0})
+
+	offset := image.NewRGBA(dest.Bounds())
+	draw.Draw(offset, offset.Bounds(), img, image.Point{X: 100, Y: 50}, draw.Src)
+	if err := compareSubImage(pixmanImg, offset, dest.Bounds(), 0); err != nil {
+		t.Errorf("Translated blit did not match expected image: %v", err)
+	}
+}
+
+// Upscale the top-left corner of img by an integer factor, sampling the nearest source pixel
+func nearestUpscale(img image.Image, factor int, size image.Point) *image.RGBA {
+	result := image.NewRGBA(image.Rectangle{Max: size})
+	for y := range size.Y {
+		for x := range size.X {
+			result.Set(x, y, img.At(x/factor, y/factor))
+		}
+	}
+	return result
+}
+
+// Upscale the top-left corner of img by an integer factor, interpolating between the four nearest source pixels
+func bilinearUpscale(img image.Image, factor int, size image.Point) *image.RGBA {
+	result := image.NewRGBA(image.Rectangle{Max: size})
+	for y := range size.Y {
+		for x := range size.X {
+			sx := (float64(x)+0.5)/float64(factor) - 0.5
+			sy := (float64(y)+0.5)/float64(factor) - 0.5
+			x0, y0 := int(math.Floor(sx)), int(math.Floor(sy))
+			fx, fy := sx-float64(x0), sy-float64(y0)
+			var channels [4]float64
+			for _, s := range []struct {
+				x, y   int
+				weight float64
+			}{
+				{x0, y0, (1 - fx) * (1 - fy)},
+				{x0 + 1, y0, fx * (1 - fy)},
+				{x0, y0 + 1, (1 - fx) * fy},
+				{x0 + 1, y0 + 1, fx * fy},
+			} {
+				r, g, b, a := img.At(s.x, s.y).RGBA()
+				channels[0] += float64(r>>8) * s.weight
+				channels[1] += float64(g>>8) * s.weight
+				channels[2] += float64(b>>8) * s.weight
+				channels[3] += float64(a>>8) * s.weight
+			}
+			result.SetRGBA(x, y, color.RGBA{
+				R: uint8(math.Round(channels[0])),
+				G: uint8(math.Round(channels[1])),
+				B: uint8(math.Round(channels[2])),
+				A: uint8(math.Round(channels[3])),
+			})
+		}
+	}
+	return result
+}
+
+func TestImageFilter(t *testing.T) {
+	img, err := loadFile("testdata/pg-coral.png")
+	if err != nil {
+		t.Fatalf("failed to load image: %v", err)
+	}
+	const factor = 2
+	size := image.Point{X: 200, Y: 200}
+	upscale := func(filter PixmanFilter) *Image {
+		srcImage, err := ImageFromImage(img)
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		scale := TransformScale(1.0/factor, 1.0/factor)
+		if err := srcImage.SetTransform(&scale); err != nil {
+			t.Fatalf("failed to set transform: %v", err)
+		}
+		if err := srcImage.SetFilter(filter); err != nil {
+			t.Fatalf("failed to set filter: %v", err)
+		}
+		dest, err := ImageFromImage(image.NewRGBA(image.Rectangle{Max: size}))
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		dest.Composite(srcImage, dest.Bounds(), image.Point{X: 0, Y: 0})
+		return dest
+	}
+
+	nearest := upscale(PIXMAN_FILTER_NEAREST)
+	if err := compareSubImage(nearest, nearestUpscale(img, factor, size), nearest.Bounds(), 0); err != nil {
+		t.Errorf("Nearest upscale did not match expected image: %v", err)
+	}
+
+	// Pixman interpolates with reduced precision, and the outermost pixels blend with the transparent surroundings
+	bilinear := upscale(PIXMAN_FILTER_BILINEAR)
+	interior := bilinear.Bounds().Inset(1)
+	if err := compareSubImage(bilinear, bilinearUpscale(img, factor, size), interior, 2); err != nil {
+		t.Errorf("Bilinear upscale did not match expected image: %v", err)
+	}
+	if err := compareSubImage(bilinear, nearest, interior, 0); err == nil {
+		t.Errorf("Bilinear upscale was identical to nearest upscale")
+	}
+}
+
+// Build an opaque black and white checkerboard of single pixel squares
+func checkerboard(size int) *image.RGBA {
+	img := image.NewRGBA(image.Rect(0, 0, size, size))
+	for y := range size {
+		for x := range size {
+			if (x+y)%2 == 0 {
+				img.SetRGBA(x, y, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+			} else {
+				img.SetRGBA(x, y, color.RGBA{A: 255})
+			}
+		}
+	}
+	return img
+}
+
+// Downscaling a checkerboard with a symmetric kernel should average it to mid-grey, rather than aliasing to black or white
+func TestImageSeparableFilter(t *testing.T) {
+	kernels := []PixmanKernel{PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_LINEAR, PIXMAN_KERNEL_CUBIC, PIXMAN_KERNEL_GAUSSIAN, PIXMAN_KERNEL_LANCZOS3}
+	for _, kernel := range kernels {
+		srcImage, err := ImageFromImage(checkerboard(64))
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		scale := TransformScale(2, 2)
+		if err := srcImage.SetTransform(&scale); err != nil {
+			t.Fatalf("failed to set transform: %v", err)
+		}
+		if err := srcImage.SetSeparableFilter(PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, kernel, kernel, 4); err != nil {
+			t.Fatalf("failed to set separable filter %s: %v", kernel, err)
+		}
+		dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 32, 32)))
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		dest.Composite(srcImage, dest.Bounds(), image.Point{X: 0, Y: 0})
+
+		grey := &image.Uniform{C: color.RGBA{R: 128, G: 128, B: 128, A: 255}}
+		// Edge pixels pick up the transparent surroundings of the source
+		if err := compareSubImage(dest, grey, dest.Bounds().Inset(4), 3); err != nil {
+			t.Errorf("Downscale with %s did not average to grey: %v", kernel, err)
+		}
+	}
+}
+
+// With a rotated, non-uniform transform the filter width along each source axis comes from the matching row of the transform
+func TestImageSeparableFilterRotated(t *testing.T) {
+	// Alternating black and white columns
+	stripes := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	for y := range 16 {
+		for x := range 32 {
+			if x%2 == 0 {
+				stripes.Set(x, y, color.White)
+			} else {
+				stripes.Set(x, y, color.Black)
+			}
+		}
+	}
+	srcImage, err := ImageFromImage(stripes)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	// Destination y steps two source columns at a time, landing on the centre of every odd column,
+	// so only a filter two source pixels wide horizontally averages the stripes
+	transform := Transform{Matrix: [3][3]PixmanFixed{
+		{0, FixedFromInt(-2), FixedFromFloat(32.5)},
+		{FixedFromInt(1), 0, 0},
+		{0, 0, FixedFromInt(1)},
+	}}
+	if err := srcImage.SetTransform(&transform); err != nil {
+		t.Fatalf("failed to set transform: %v", err)
+	}
+	if err := srcImage.SetSeparableFilter(PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, 4); err != nil {
+		t.Fatalf("failed to set separable filter: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 16, 16)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Composite(srcImage, dest.Bounds(), image.Point{})
+	grey := &image.Uniform{C: color.RGBA{R: 128, G: 128, B: 128, A: 255}}
+	if err := compareSubImage(dest, grey, dest.Bounds().Inset(2), 3); err != nil {
+		t.Errorf("Rotated downscale did not average the stripes to grey: %v", err)
+	}
+}
+
+// The separable convolution parameters are built in Go, so check them against pixman's layout
+func TestSeparableConvolutionParams(t *testing.T) {
+	// A box sampled at unit scale covers exactly the pixel under the sample point
+	params, err := separableConvolution(FixedFromInt(1), FixedFromInt(1), PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, 0, 0)
+	if err != nil {
+		t.Fatalf("failed to create separable convolution: %v", err)
+	}
+	expected := []PixmanFixed{FixedFromInt(2), FixedFromInt(2), 0, 0, 0, FixedFromInt(1), 0, FixedFromInt(1)}
+	if fmt.Sprint(params) != fmt.Sprint(expected) {
+		t.Errorf("Box filter parameters are %v, expected %v", params, expected)
+	}
+
+	kernels := []PixmanKernel{PIXMAN_KERNEL_IMPULSE, PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_LINEAR, PIXMAN_KERNEL_CUBIC, PIXMAN_KERNEL_GAUSSIAN, PIXMAN_KERNEL_LANCZOS2, PIXMAN_KERNEL_LANCZOS3, PIXMAN_KERNEL_LANCZOS3_STRETCHED}
+	for _, kernel := range kernels {
+		params, err := separableConvolution(FixedFromFloat(3.5), FixedFromFloat(0.5), PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_LINEAR, kernel, kernel, 2, 3)
+		if err != nil {
+			t.Fatalf("failed to create %s separable convolution: %v", kernel, err)
+		}
+		width, height := params[0].Int(), params[1].Int()
+		if params[2].Int() != 2 || params[3].Int() != 3 {
+			t.Errorf("%s: subsample bits are %d, %d, expected 2, 3", kernel, params[2].Int(), params[3].Int())
+		}
+		if len(params) != 4+width*4+height*8 {
+			t.Fatalf("%s: %d parameters for a %dx%d filter", kernel, len(params), width, height)
+		}
+		// Every phase must sum to exactly one, so filtering a flat image leaves it unchanged
+		taps := params[4:]
+		for phase := range 12 {
+			n := width
+			if phase >= 4 {
+				n = height
+			}
+			sum := PixmanFixed(0)
+			for _, weight := range taps[:n] {
+				sum += weight
+			}
+			if sum != FixedFromInt(1) {
+				t.Errorf("%s: phase %d sums to %#x, expected 0x10000", kernel, phase, sum)
+			}
+			taps = taps[n:]
+		}
+	}
+
+	if _, err := separableConvolution(0, FixedFromInt(1), PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, 4, 4); err == nil {
+		t.Errorf("Separable convolution with a zero scale did not fail")
+	}
+	if _, err := separableConvolution(FixedFromInt(1), FixedFromInt(1), PixmanKernel(99), PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, PIXMAN_KERNEL_BOX, 4, 4); err == nil {
+		t.Errorf("Separable convolution with an unknown kernel did not fail")
+	}
+}
+
+func TestImageConvolution(t *testing.T) {
+	img, err := loadFile("testdata/pg-coral.png")
+	if err != nil {
+		t.Fatalf("failed to load image: %v", err)
+	}
+	srcImage, err := ImageFromImage(img)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if err := srcImage.SetConvolution([][]float64{{0, 0}, {0, 1}}); err == nil {
+		t.Errorf("expected an error for an even sized kernel")
+	}
+	if err := srcImage.SetConvolution([][]float64{{0, 0, 0}, {0, 1, 0}, {0, 0, 0}}); err != nil {
+		t.Fatalf("failed to set convolution: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(img.Bounds()))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Composite(srcImage, img.Bounds(), image.Point{X: 0, Y: 0})
+	if err := compareSubImage(dest, img, img.Bounds(), 0); err != nil {
+		t.Errorf("Identity convolution did not match original image: %v", err)
+	}
+}
+
+func TestImageBlur(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+	draw.Draw(img, img.Bounds(), &image.Uniform{C: red}, image.Point{}, draw.Src)
+	pixmanImg, err := ImageFromImage(img)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if err := pixmanImg.BoxBlur(2); err != nil {
+		t.Fatalf("failed to box blur: %v", err)
+	}
+	// Blurring a uniform colour only changes the edges, which blend with the transparent surroundings
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: red}, img.Bounds().Inset(2), 1); err != nil {
+		t.Errorf("Box blur changed the interior of a uniform image: %v", err)
+	}
+	if _, _, _, a := pixmanImg.At(0, 0).RGBA(); a>>8 >= 255 {
+		t.Errorf("Box blur did not soften the image corner")
+	}
+
+	// A single white dot should spread out symmetrically
+	dot := image.NewRGBA(image.Rect(0, 0, 15, 15))
+	dot.SetRGBA(7, 7, color.RGBA{R: 255, G: 255, B: 255, A: 255})
+	pixmanDot, err := ImageFromImage(dot)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if err := pixmanDot.GaussianBlur(1); err != nil {
+		t.Fatalf("failed to gaussian blur: %v", err)
+	}
+	centre := pixmanDot.At(7, 7)
+	if colorMatch(centre, color.White, 0) || colorMatch(centre, color.Transparent, 0) {
+		t.Errorf("Gaussian blur did not spread the dot: centre is %v", centre)
+	}
+	for _, p := range []image.Point{{X: 6, Y: 7}, {X: 8, Y: 7}, {X: 7, Y: 6}, {X: 7, Y: 8}} {
+		if !colorMatch(pixmanDot.At(p.X, p.Y), pixmanDot.At(6, 7), 1) {
+			t.Errorf("Gaussian blur is not symmetric at %v: %v vs %v", p, pixmanDot.At(p.X, p.Y), pixmanDot.At(6, 7))
+		}
+	}
+}
+
+// Blurring a flat colour must leave it exactly unchanged away from the edges, which needs the
+// fixed point weights of each normalised kernel to sum to exactly one
+func TestImageBlurFlat(t *testing.T) {
+	for _, kernel := range [][]float64{{0.2, 0.2, 0.2, 0.2, 0.2}, gaussianKernel(1.5), gaussianKernel(3)} {
+		params, err := convolutionParams([][]float64{kernel})
+		if err != nil {
+			t.Fatalf("failed to pack convolution kernel: %v", err)
+		}
+		sum := PixmanFixed(0)
+		for _, weight := range params[2:] {
+			sum += weight
+		}
+		if sum != FixedFromInt(1) {
+			t.Errorf("%d tap kernel weights sum to %#x, expected 0x10000", len(kernel), sum)
+		}
+	}
+
+	grey := color.RGBA{R: 200, G: 200, B: 200, A: 255}
+	blurs := []struct {
+		name  string
+		inset int
+		blur  func(*Image) error
+	}{
+		{"box", 2, func(i *Image) error { return i.BoxBlur(2) }},
+		{"gaussian", 5, func(i *Image) error { return i.GaussianBlur(1.5) }},
+	}
+	for _, test := range blurs {
+		img := image.NewRGBA(image.Rect(0, 0, 32, 32))
+		draw.Draw(img, img.Bounds(), &image.Uniform{C: grey}, image.Point{}, draw.Src)
+		pixmanImg, err := ImageFromImage(img)
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		if err := test.blur(pixmanImg); err != nil {
+			t.Fatalf("failed to %s blur: %v", test.name, err)
+		}
+		if err := compareSubImage(pixmanImg, &image.Uniform{C: grey}, img.Bounds().Inset(test.inset), 0); err != nil {
+			t.Errorf("%s blur changed a flat colour: %v", test.name, err)
+		}
+	}
+}
+
+func TestImageUnsharpMask(t *testing.T) {
+	// A vertical edge between two greys, with the whole image opaque
+	dark := color.RGBA{R: 64, G: 64, B: 64, A: 255}
+	light := color.RGBA{R: 192, G: 192, B: 192, A: 255}
+	img := image.NewRGBA(image.Rect(0, 0, 32, 16))
+	draw.Draw(img, image.Rect(0, 0, 16, 16), &image.Uniform{C: dark}, image.Point{}, draw.Src)
+	draw.Draw(img, image.Rect(16, 0, 32, 16), &image.Uniform{C: light}, image.Point{}, draw.Src)
+	pixmanImg, err := ImageFromImage(img)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if err := pixmanImg.UnsharpMask(1, 4); err != nil {
+		t.Fatalf("failed to unsharp mask: %v", err)
+	}
+	// The kernel reaches three pixels, so flat areas further than that from the edge and the image border are unchanged
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: dark}, image.Rect(3, 3, 13, 13), 1); err != nil {
+		t.Errorf("Unsharp mask changed the flat dark area: %v", err)
+	}
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: light}, image.Rect(19, 3, 29, 13), 1); err != nil {
+		t.Errorf("Unsharp mask changed the flat light area: %v", err)
+	}
+	// Either side of the edge the overshoot goes past black and white, and must be clamped rather than wrap around
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: color.Black}, image.Rect(15, 3, 16, 13), 0); err != nil {
+		t.Errorf("Unsharp mask overshoot on the dark side of the edge was not clamped to black: %v", err)
+	}
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: color.White}, image.Rect(16, 3, 17, 13), 0); err != nil {
+		t.Errorf("Unsharp mask overshoot on the light side of the edge was not clamped to white: %v", err)
+	}
+}
+
+func TestImageRepeat(t *testing.T) {
+	a := color.RGBA{R: 255, A: 255}
+	b := color.RGBA{G: 255, A: 255}
+	c := color.RGBA{B: 255, A: 255}
+	tests := []struct {
+		repeat   PixmanRepeat
+		expected []color.Color
+	}{
+		{PIXMAN_REPEAT_NONE, []color.Color{color.Transparent, color.Transparent, color.Transparent, a, b, c, color.Transparent, color.Transparent, color.Transparent}},
+		{PIXMAN_REPEAT_NORMAL, []color.Color{a, b, c, a, b, c, a, b, c}},
+		{PIXMAN_REPEAT_PAD, []color.Color{a, a, a, a, b, c, c, c, c}},
+		{PIXMAN_REPEAT_REFLECT, []color.Color{c, b, a, a, b, c, c, b, a}},
+	}
+	for _, test := range tests {
+		src := image.NewRGBA(image.Rect(0, 0, 3, 1))
+		src.Set(0, 0, a)
+		src.Set(1, 0, b)
+		src.Set(2, 0, c)
+		srcImage, err := ImageFromImage(src)
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		srcImage.SetRepeat(test.repeat)
+
+		dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 9, 1)))
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		dest.Composite(srcImage, image.Rect(-3, 0, 6, 1), image.Point{X: 0, Y: 0})
+		for x, expected := range test.expected {
+			if !colorMatch(dest.At(x, 0), expected, 0) {
+				t.Errorf("%s: pixel %d is %v, expected %v", test.repeat, x, dest.At(x, 0), expected)
+			}
+		}
+	}
+}
+
+func TestLinearGradient(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	if _, err := ImageLinearGradient(PointFixedFromFloat(0, 0), PointFixedFromFloat(100, 0), nil); err == nil {
+		t.Errorf("expected an error creating a gradient without stops")
+	}
+	// Stops are deliberately out of order
+	gradient, err := ImageLinearGradient(PointFixedFromFloat(0, 0), PointFixedFromFloat(100, 0), []GradientStop{
+		{Offset: 1, Color: blue},
+		{Offset: 0, Color: red},
+	})
+	if err != nil {
+		t.Fatalf("failed to create linear gradient: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 10)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Composite(gradient, dest.Bounds(), image.Point{X: 0, Y: 0})
+
+	for _, x := range []int{0, 25, 50, 75, 99} {
+		// Pixels are sampled at their centres
+		pos := (float64(x) + 0.5) / 100
+		expected := color.RGBA{R: uint8(255 * (1 - pos)), B: uint8(255 * pos), A: 255}
+		for y := range 10 {
+			if !colorMatch(dest.At(x, y), expected, 2) {
+				t.Errorf("Gradient pixel at (%d,%d) is %v, expected %v", x, y, dest.At(x, y), expected)
+			}
+		}
+	}
+}
+
+func TestRadialGradient(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	centre := PointFixedFromFloat(50, 50)
+	gradient, err := ImageRadialGradient(centre, centre, 0, FixedFromInt(50), []GradientStop{
+		{Offset: 0, Color: red},
+		{Offset: 1, Color: blue},
+	})
+	if err != nil {
+		t.Fatalf("failed to create radial gradient: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 100)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Composite(gradient, dest.Bounds(), image.Point{X: 0, Y: 0})
+
+	for _, p := range []image.Point{{X: 50, Y: 50}, {X: 75, Y: 50}, {X: 50, Y: 20}, {X: 30, Y: 60}} {
+		pos := math.Hypot(float64(p.X)+0.5-50, float64(p.Y)+0.5-50) / 50
+		expected := color.RGBA{R: uint8(255 * (1 - pos)), B: uint8(255 * pos), A: 255}
+		if !colorMatch(dest.At(p.X, p.Y), expected, 3) {
+			t.Errorf("Radial gradient pixel at %v is %v, expected %v", p, dest.At(p.X, p.Y), expected)
+		}
+	}
+}
+
+func TestConicalGradient(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	gradient, err := ImageConicalGradient(PointFixedFromFloat(50, 50), 0, []GradientStop{
+		{Offset: 0, Color: red},
+		{Offset: 0.5, Color: blue},
+		{Offset: 1, Color: red},
+	})
+	if err != nil {
+		t.Fatalf("failed to create conical gradient: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 100, 100)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Composite(gradient, dest.Bounds(), image.Point{X: 0, Y: 0})
+
+	// The sweep starts and ends on the positive x axis, so the opposite side is half way through
+	if !colorMatch(dest.At(95, 50), red, 8) {
+		t.Errorf("Conical gradient start is %v, expected %v", dest.At(95, 50), red)
+	}
+	if !colorMatch(dest.At(5, 50), blue, 8) {
+		t.Errorf("Conical gradient midpoint is %v, expected %v", dest.At(5, 50), blue)
+	}
+}
+
+func TestCompositeOp(t *testing.T) {
+	size := image.Point{X: 16, Y: 16}
+	newDest := func(col color.Color) *Image {
+		img := image.NewRGBA(image.Rectangle{Max: size})
+		draw.Draw(img, img.Bounds(), &image.Uniform{C: col}, image.Point{}, draw.Src)
+		dest, err := ImageFromImage(img)
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		return dest
+	}
+	newSolid := func(col color.Color) *Image {
+		solid, err := ImageSolid(col)
+		if err != nil {
+			t.Fatalf("failed to create solid image: %v", err)
+		}
+		return solid
+	}
+
+	translucent := color.RGBA{R: 0, G: 64, B: 0, A: 64}
+	dest := newDest(color.RGBA{R: 255, A: 255})
+	dest.CompositeOp(PIXMAN_OP_SRC, newSolid(translucent), nil, image.Point{}, image.Point{}, image.Point{}, size)
+	if err := compareSubImage(dest, &image.Uniform{C: translucent}, dest.Bounds(), 0); err != nil {
+		t.Errorf("SRC did not replace the destination: %v", err)
+	}
+
+	dest = newDest(color.RGBA{R: 100, A: 255})
+	dest.CompositeOp(PIXMAN_OP_ADD, newSolid(color.RGBA{R: 200, G: 50, A: 255}), nil, image.Point{}, image.Point{}, image.Point{}, size)
+	if err := compareSubImage(dest, &image.Uniform{C: color.RGBA{R: 255, G: 50, A: 255}}, dest.Bounds(), 0); err != nil {
+		t.Errorf("ADD did not saturate: %v", err)
+	}
+
+	// Only the left half of the mask is set, at half opacity
+	maskImg := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(maskImg, image.Rect(0, 0, size.X/2, size.Y), &image.Uniform{C: color.RGBA{A: 128}}, image.Point{}, draw.Src)
+	mask, err := ImageFromImage(maskImg)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest = newDest(color.Transparent)
+	dest.CompositeOp(PIXMAN_OP_OVER, newSolid(color.RGBA{R: 255, A: 255}), mask, image.Point{}, image.Point{}, image.Point{}, size)
+	if err := compareSubImage(dest, &image.Uniform{C: color.RGBA{R: 128, A: 128}}, image.Rect(0, 0, size.X/2, size.Y), 1); err != nil {
+		t.Errorf("Masked composite did not apply the mask alpha: %v", err)
+	}
+	if err := compareSubImage(dest, &image.Uniform{C: color.Transparent}, image.Rect(size.X/2, 0, size.X, size.Y), 0); err != nil {
+		t.Errorf("Masked composite drew outside the mask: %v", err)
+	}
+}
+
+func TestRegion(t *testing.T) {
+	a, err := RegionFromRects(image.Rect(0, 0, 10, 10))
+	if err != nil {
+		t.Fatalf("failed to create region: %v", err)
+	}
+	b, err := RegionFromRects(image.Rect(5, 5, 15, 15))
+	if err != nil {
+		t.Fatalf("failed to create region: %v", err)
+	}
+
+	union, err := a.Union(b)
+	if err != nil {
+		t.Fatalf("failed to union regions: %v", err)
+	}
+	if union.Extents() != image.Rect(0, 0, 15, 15) {
+		t.Errorf("union extents are %v", union.Extents())
+	}
+	area := 0
+	for _, r := range union.Rects() {
+		area += r.Dx() * r.Dy()
+	}
+	if area != 175 {
+		t.Errorf("union area is %d, expected 175: %v", area, union)
+	}
+
+	intersect, err := a.Intersect(b)
+	if err != nil {
+		t.Fatalf("failed to intersect regions: %v", err)
+	}
+	expected, err := RegionFromRects(image.Rect(5, 5, 10, 10))
+	if err != nil {
+		t.Fatalf("failed to create region: %v", err)
+	}
+	if !intersect.Equal(expected) {
+		t.Errorf("intersection is %v, expected %v", intersect, expected)
+	}
+
+	subtract, err := a.Subtract(b)
+	if err != nil {
+		t.Fatalf("failed to subtract regions: %v", err)
+	}
+	if subtract.ContainsPoint(image.Pt(7, 7)) || !subtract.ContainsPoint(image.Pt(2, 2)) {
+		t.Errorf("subtraction has wrong contents: %v", subtract)
+	}
+	if overlap := subtract.ContainsRect(image.Rect(0, 0, 10, 10)); overlap != PIXMAN_REGION_PART {
+		t.Errorf("subtraction overlap is %s, expected PIXMAN_REGION_PART", overlap)
+	}
+
+	inverse, err := a.Inverse(image.Rect(0, 0, 10, 20))
+	if err != nil {
+		t.Fatalf("failed to invert region: %v", err)
+	}
+	if !inverse.Equal(mustRegion(t, image.Rect(0, 10, 10, 20))) {
+		t.Errorf("inverse is %v", inverse)
+	}
+
+	intersect.Translate(-5, -5)
+	if !intersect.Equal(mustRegion(t, image.Rect(0, 0, 5, 5))) {
+		t.Errorf("translated region is %v", intersect)
+	}
+	if intersect.ContainsRect(image.Rect(1, 1, 3, 3)) != PIXMAN_REGION_IN {
+		t.Errorf("translated region does not contain inner rectangle")
+	}
+
+	empty, err := RegionFromRects()
+	if err != nil {
+		t.Fatalf("failed to create empty region: %v", err)
+	}
+	if !empty.Empty() || a.Empty() {
+		t.Errorf("region emptiness is wrong")
+	}
+}
+
+func mustRegion(t *testing.T, rects ...image.Rectangle) *Region {
+	region, err := RegionFromRects(rects...)
+	if err != nil {
+		t.Fatalf("failed to create region: %v", err)
+	}
+	return region
+}
+
+func TestImageClipRegion(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	pixmanImg, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 20, 20)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	clip := mustRegion(t, image.Rect(0, 0, 5, 5), image.Rect(10, 10, 20, 20))
+	if err := pixmanImg.SetClipRegion(clip); err != nil {
+		t.Fatalf("failed to set clip region: %v", err)
+	}
+	pixmanImg.Fill(pixmanImg.Bounds(), red)
+	for y := range 20 {
+		for x := range 20 {
+			expected := color.Color(color.Transparent)
+			if clip.ContainsPoint(image.Pt(x, y)) {
+				expected = red
+			}
+			if !colorMatch(pixmanImg.At(x, y), expected, 0) {
+				t.Fatalf("Clipped fill pixel at (%d,%d) is %v, expected %v", x, y, pixmanImg.At(x, y), expected)
+			}
+		}
+	}
+
+	solid, err := ImageSolid(blue)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	pixmanImg.Composite(solid, image.Rect(0, 0, 20, 20), image.Point{X: 0, Y: 0})
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: blue}, image.Rect(10, 10, 20, 20), 0); err != nil {
+		t.Errorf("Clipped composite did not draw inside the clip: %v", err)
+	}
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: color.Transparent}, image.Rect(5, 0, 20, 10), 0); err != nil {
+		t.Errorf("Clipped composite drew outside the clip: %v", err)
+	}
+
+	pixmanImg.ClearClip()
+	pixmanImg.Fill(pixmanImg.Bounds(), red)
+	if err := compareSubImage(pixmanImg, &image.Uniform{C: red}, pixmanImg.Bounds(), 0); err != nil {
+		t.Errorf("Fill after clearing the clip did not cover the image: %v", err)
+	}
+}
+
+func TestImageSourceClipping(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	// A sprite sheet with a red sprite on the left and a blue sprite on the right
+	sheet := image.NewRGBA(image.Rect(0, 0, 8, 4))
+	draw.Draw(sheet, image.Rect(0, 0, 4, 4), &image.Uniform{C: red}, image.Point{}, draw.Src)
+	draw.Draw(sheet, image.Rect(4, 0, 8, 4), &image.Uniform{C: blue}, image.Point{}, draw.Src)
+	srcImage, err := ImageFromImage(sheet)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if err := srcImage.SetSourceClipRegion(mustRegion(t, image.Rect(0, 0, 4, 4))); err != nil {
+		t.Fatalf("failed to set source clip region: %v", err)
+	}
+
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 8, 4)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Composite(srcImage, sheet.Bounds(), image.Point{X: 0, Y: 0})
+	if err := compareSubImage(dest, &image.Uniform{C: red}, image.Rect(0, 0, 4, 4), 0); err != nil {
+		t.Errorf("Source clipped composite did not draw the clipped sprite: %v", err)
+	}
+	if err := compareSubImage(dest, &image.Uniform{C: color.Transparent}, image.Rect(4, 0, 8, 4), 0); err != nil {
+		t.Errorf("Source clipped composite drew the neighbouring sprite: %v", err)
+	}
+
+	// Without source clipping, the clip region is ignored for sources
+	srcImage.SetSourceClipping(false)
+	dest.Composite(srcImage, sheet.Bounds(), image.Point{X: 0, Y: 0})
+	if err := compareSubImage(dest, sheet, sheet.Bounds(), 0); err != nil {
+		t.Errorf("Composite without source clipping did not draw the whole sheet: %v", err)
+	}
+
+	// Source clipping only limits the destination pixels written, so a filter still samples the neighbouring
+	// sprite at the clip edge. A sprite copied out of the sheet pads its own edges instead. Shifted by half a
+	// pixel, the last column samples halfway past the sprite's edge and must stay red.
+	sprite, err := srcImage.Sprite(image.Rect(0, 0, 4, 4))
+	if err != nil {
+		t.Fatalf("failed to copy sprite: %v", err)
+	}
+	shift := TransformTranslate(0.5, 0)
+	if err := sprite.SetTransform(&shift); err != nil {
+		t.Fatalf("failed to set transform: %v", err)
+	}
+	if err := sprite.SetFilter(PIXMAN_FILTER_BILINEAR); err != nil {
+		t.Fatalf("failed to set filter: %v", err)
+	}
+	dest, err = ImageFromImage(image.NewRGBA(image.Rect(0, 0, 8, 4)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.Composite(sprite, sprite.Bounds(), image.Point{X: 0, Y: 0})
+	if err := compareSubImage(dest, &image.Uniform{C: red}, image.Rect(0, 0, 4, 4), 0); err != nil {
+		t.Errorf("Filtered sprite bled in its neighbour: %v", err)
+	}
+	if err := compareSubImage(dest, &image.Uniform{C: color.Transparent}, image.Rect(4, 0, 8, 4), 0); err != nil {
+		t.Errorf("Filtered sprite drew outside its bounds: %v", err)
+	}
+	if _, err := srcImage.Sprite(image.Rect(6, 0, 10, 4)); err == nil {
+		t.Errorf("Sprite accepted a rectangle outside the image")
+	}
+}
+
+func TestRasterizeTrapezoid(t *testing.T) {
+	mask, err := ImageFromBits(PIXMAN_a8, 16, 16, make([]byte, 16*16), 16)
+	if err != nil {
+		t.Fatalf("failed to create mask image: %v", err)
+	}
+	// An axis aligned rectangle from (4,2) to (12,10), with a trapezoid offset of (1,1)
+	trap := PixmanTrapezoid{
+		Top:    FixedFromInt(2),
+		Bottom: FixedFromInt(10),
+		Left:   LineFixedFromFloat(4, 0, 4, 16),
+		Right:  LineFixedFromFloat(12, 0, 12, 16),
+	}
+	if err := mask.RasterizeTrapezoid(trap, 1, 1); err != nil {
+		t.Fatalf("failed to rasterize trapezoid: %v", err)
+	}
+	coverage := &image.Alpha{Pix: mask.getRawData(), Stride: 16, Rect: mask.Bounds()}
+	assertCoverage(t, coverage, []image.Rectangle{image.Rect(5, 3, 13, 11)}, color.Alpha{A: 255})
+	if math.MaxInt > math.MaxInt32 {
+		// Only a 64 bit int can hold an offset that doesn't fit pixman's int
+		offset := int64(math.MaxInt32) + 1
+		if err := mask.RasterizeTrapezoid(trap, int(offset), 0); err == nil {
+			t.Errorf("RasterizeTrapezoid accepted an x offset outside the int32 range")
+		}
+	}
+	// The same rectangle added through AddTrapezoids, offset back by (-1,-1)
+	clear(mask.getRawData())
+	if err := mask.AddTrapezoids(-1, -1, []PixmanTrapezoid{trap}); err != nil {
+		t.Fatalf("failed to add trapezoids: %v", err)
+	}
+	if got := mask.getRawData()[1*16+3]; got != 255 {
+		t.Errorf("Added trapezoid coverage at (3,1) is %d, expected 255", got)
+	}
+	if got := mask.getRawData()[2*16+2]; got != 0 {
+		t.Errorf("Added trapezoid coverage at (2,2) is %d, expected 0", got)
+	}
+	if err := mask.AddTrapezoids(1<<16, 0, []PixmanTrapezoid{trap}); err == nil {
+		t.Errorf("AddTrapezoids accepted an x offset outside the int16 range")
+	}
+}
+
+func TestCompositeTrapezoids(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	solid, err := ImageSolid(red)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 20, 20)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	// A triangle with its apex at (10,0) and base from (0,20) to (20,20)
+	CompositeTrapezoids(PIXMAN_OP_OVER, solid, dest, PIXMAN_a8, []PixmanTrapezoid{{
+		Top:    0,
+		Bottom: FixedFromInt(20),
+		Left:   LineFixedFromFloat(10, 0, 0, 20),
+		Right:  LineFixedFromFloat(10, 0, 20, 20),
+	}})
+	if !colorMatch(dest.At(10, 15), red, 0) {
+		t.Errorf("Pixel inside the triangle is %v", dest.At(10, 15))
+	}
+	if !colorMatch(dest.At(1, 1), color.Transparent, 0) || !colorMatch(dest.At(18, 1), color.Transparent, 0) {
+		t.Errorf("Pixels outside the triangle were drawn")
+	}
+	// Pixels crossed by the diagonal edge are partially covered
+	if _, _, _, a := dest.At(4, 10).RGBA(); a == 0 || a == 0xffff {
+		t.Errorf("Edge pixel is not anti-aliased: %v", dest.At(4, 10))
+	}
+}
+
+func TestCompositeTriangles(t *testing.T) {
+	blue := color.RGBA{B: 255, A: 255}
+	solid, err := ImageSolid(blue)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 20, 20)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	// A square from (4,4) to (16,16), split along its diagonal
+	CompositeTriangles(PIXMAN_OP_OVER, solid, dest, PIXMAN_a8, []PixmanTriangle{
+		TriangleFromFloat(4, 4, 16, 4, 16, 16),
+		TriangleFromFloat(4, 4, 16, 16, 4, 16),
+	})
+	for y := range 20 {
+		for x := range 20 {
+			switch {
+			case x == y:
+				// Pixels on the shared diagonal are covered by both triangles
+			case image.Pt(x, y).In(image.Rect(4, 4, 16, 16)):
+				if !colorMatch(dest.At(x, y), blue, 0) {
+					t.Errorf("Pixel inside the square at (%d,%d) is %v", x, y, dest.At(x, y))
+				}
+			default:
+				if !colorMatch(dest.At(x, y), color.Transparent, 0) {
+					t.Errorf("Pixel outside the square at (%d,%d) is %v", x, y, dest.At(x, y))
+				}
+			}
+		}
+	}
+
+	mask, err := ImageFromBits(PIXMAN_a8, 8, 8, make([]byte, 8*8), 8)
+	if err != nil {
+		t.Fatalf("failed to create mask image: %v", err)
+	}
+	if err := mask.AddTriangles(0, 0, []PixmanTriangle{TriangleFromFloat(0, 0, 8, 0, 0, 8)}); err != nil {
+		t.Fatalf("failed to add triangles: %v", err)
+	}
+	if got := mask.getRawData()[1*8+1]; got != 255 {
+		t.Errorf("Triangle coverage inside the triangle is %d", got)
+	}
+	if got := mask.getRawData()[7*8+7]; got != 0 {
+		t.Errorf("Triangle coverage outside the triangle is %d", got)
+	}
+	if math.MaxInt > math.MaxInt32 {
+		offset := int64(math.MaxInt32) + 1
+		if err := mask.AddTriangles(0, int(offset), []PixmanTriangle{TriangleFromFloat(0, 0, 8, 0, 0, 8)}); err == nil {
+			t.Errorf("AddTriangles accepted a y offset outside the int32 range")
+		}
+	}
+}
+
+func TestFillPath(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	solid, err := ImageSolid(red)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	// Two nested squares wound in the same direction, and a curved blob to the side
+	var path Path
+	for _, r := range []image.Rectangle{image.Rect(2, 2, 18, 18), image.Rect(6, 6, 14, 14)} {
+		path.MoveTo(float64(r.Min.X), float64(r.Min.Y))
+		path.LineTo(float64(r.Max.X), float64(r.Min.Y))
+		path.LineTo(float64(r.Max.X), float64(r.Max.Y))
+		path.LineTo(float64(r.Min.X), float64(r.Max.Y))
+		path.Close()
+	}
+	path.MoveTo(30, 2)
+	path.CubicTo(40, 2, 40, 18, 30, 18)
+	path.QuadTo(20, 10, 30, 2)
+	path.Close()
+
+	tests := []struct {
+		rule       FillRule
+		innerColor color.Color
+	}{
+		{FillRuleNonZero, red},
+		{FillRuleEvenOdd, color.Transparent},
+	}
+	for _, test := range tests {
+		dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 40, 20)))
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		FillPath(dest, &path, solid, test.rule)
+		if err := compareSubImage(dest, &image.Uniform{C: red}, image.Rect(2, 2, 18, 6), 0); err != nil {
+			t.Errorf("Fill rule %d did not fill the outer square: %v", test.rule, err)
+		}
+		if err := compareSubImage(dest, &image.Uniform{C: test.innerColor}, image.Rect(6, 6, 14, 14), 0); err != nil {
+			t.Errorf("Fill rule %d gave the wrong inner square: %v", test.rule, err)
+		}
+		if err := compareSubImage(dest, &image.Uniform{C: color.Transparent}, image.Rect(18, 0, 24, 20), 0); err != nil {
+			t.Errorf("Fill rule %d drew outside the path: %v", test.rule, err)
+		}
+		if !colorMatch(dest.At(30, 10), red, 0) {
+			t.Errorf("Fill rule %d did not fill the curved shape: %v", test.rule, dest.At(30, 10))
+		}
+	}
+}
+
+func TestStrokePath(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	solid, err := ImageSolid(red)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	var line Path
+	line.MoveTo(4, 10)
+	line.LineTo(36, 10)
+
+	tests := []struct {
+		name    string
+		style   StrokeStyle
+		covered []image.Rectangle
+	}{
+		{"butt", StrokeStyle{Width: 4}, []image.Rectangle{image.Rect(4, 8, 36, 12)}},
+		{"square", StrokeStyle{Width: 4, Cap: CapSquare}, []image.Rectangle{image.Rect(2, 8, 38, 12)}},
+		{"dashed", StrokeStyle{Width: 4, Dash: []float64{8, 4}}, []image.Rectangle{image.Rect(4, 8, 12, 12), image.Rect(16, 8, 24, 12), image.Rect(28, 8, 36, 12)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 40, 20)))
+			if err != nil {
+				t.Fatalf("failed to create Pixman image: %v", err)
+			}
+			StrokePath(dest, &line, solid, test.style)
+			assertCoverage(t, dest, test.covered, red)
+		})
+	}
+
+	// A mitred corner fills the outside of the bend, a bevelled one only half of it
+	var corner Path
+	corner.MoveTo(4, 10)
+	corner.LineTo(20, 10)
+	corner.LineTo(20, 0)
+	for _, join := range []LineJoin{JoinMiter, JoinBevel} {
+		dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 40, 20)))
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		StrokePath(dest, &corner, solid, StrokeStyle{Width: 4, Join: join})
+		_, _, _, a := dest.At(21, 11).RGBA()
+		if (join == JoinMiter) != (a == 0xffff) {
+			t.Errorf("Join %d corner pixel is %v", join, dest.At(21, 11))
+		}
+	}
+}
+
+func TestShapes(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	blue := color.RGBA{B: 255, A: 255}
+	solid, err := ImageSolid(red)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	// Shapes are drawn over a blue background, which must survive outside them whatever the operator
+	newDest := func() *Image {
+		dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 40, 40)))
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		dest.Fill(dest.Bounds(), blue)
+		return dest
+	}
+	tests := []struct {
+		name    string
+		draw    func(dest *Image) error
+		inside  color.Color
+		filled  []image.Point
+		outside []image.Point
+	}{
+		{"rounded rect", func(dest *Image) error { return dest.FillRoundedRect(PIXMAN_OP_OVER, solid, 4, 4, 32, 24, 8) }, red,
+			[]image.Point{{20, 16}, {5, 16}, {20, 5}, {30, 26}},
+			[]image.Point{{4, 4}, {35, 4}, {4, 27}, {35, 27}, {20, 30}}},
+		{"ellipse", func(dest *Image) error { return dest.FillEllipse(PIXMAN_OP_SRC, solid, 20, 20, 16, 8) }, red,
+			[]image.Point{{20, 20}, {5, 20}, {20, 13}},
+			[]image.Point{{5, 13}, {34, 26}, {20, 10}, {20, 30}, {0, 0}, {39, 39}}},
+		{"circle", func(dest *Image) error { return dest.FillCircle(PIXMAN_OP_OVER, solid, 20, 20, 10) }, red,
+			[]image.Point{{20, 20}, {11, 20}, {20, 28}},
+			[]image.Point{{11, 11}, {28, 28}, {20, 8}}},
+		{"cleared circle", func(dest *Image) error { return dest.FillCircle(PIXMAN_OP_CLEAR, solid, 20, 20, 10) }, color.Transparent,
+			[]image.Point{{20, 20}, {11, 20}, {20, 28}},
+			[]image.Point{{11, 11}, {28, 28}, {20, 8}, {0, 0}, {39, 39}}},
+		{"stroked ellipse", func(dest *Image) error { return dest.StrokeEllipse(PIXMAN_OP_SRC, solid, 20, 20, 16, 12, 4) }, red,
+			[]image.Point{{35, 19}, {4, 20}, {19, 31}},
+			[]image.Point{{20, 20}, {10, 20}, {2, 2}, {39, 39}}},
+	}
+	for _, test := range tests {
+		dest := newDest()
+		if err := test.draw(dest); err != nil {
+			t.Fatalf("failed to draw %s: %v", test.name, err)
+		}
+		for _, pt := range test.filled {
+			if !colorMatch(dest.At(pt.X, pt.Y), test.inside, 0) {
+				t.Errorf("%s pixel at %v is %v, expected %v", test.name, pt, dest.At(pt.X, pt.Y), test.inside)
+			}
+		}
+		for _, pt := range test.outside {
+			if !colorMatch(dest.At(pt.X, pt.Y), blue, 0) {
+				t.Errorf("%s pixel at %v is %v, expected the background to be unchanged", test.name, pt, dest.At(pt.X, pt.Y))
+			}
+		}
+	}
+}
+
+func TestDrawLine(t *testing.T) {
+	red := color.RGBA{R: 255, A: 255}
+	solid, err := ImageSolid(red)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	DrawLine(dest, PointF{X: 4.5, Y: 10}, PointF{X: 36, Y: 10}, 4, solid)
+	for y := range 20 {
+		for x := range 40 {
+			expected := color.Color(color.Transparent)
+			delta := uint32(0)
+			if y >= 8 && y < 12 && x >= 4 && x < 36 {
+				expected = red
+				if x == 4 {
+					// The line starts half way across this column, which the rasterizer's sample grid only approximates
+					expected = color.RGBA{R: 128, A: 128}
+					delta = 16
+				}
+			}
+			if !colorMatch(dest.At(x, y), expected, delta) {
+				t.Fatalf("Line pixel at (%d,%d) is %v, expected %v", x, y, dest.At(x, y), expected)
+			}
+		}
+	}
+
+	// Overlapping segments at a corner stay opaque, and the outside of the corner is bevelled
+	dest, err = ImageFromImage(image.NewRGBA(image.Rect(0, 0, 40, 20)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	DrawPolyline(dest, []PointF{{X: 4, Y: 10}, {X: 20, Y: 10}, {X: 20, Y: 0}}, 4, solid)
+	for _, pt := range []image.Point{{10, 9}, {19, 9}, {19, 11}, {21, 2}} {
+		if !colorMatch(dest.At(pt.X, pt.Y), red, 0) {
+			t.Errorf("Polyline pixel at %v is %v, expected %v", pt, dest.At(pt.X, pt.Y), red)
+		}
+	}
+	if _, _, _, a := dest.At(21, 11).RGBA(); a == 0xffff {
+		t.Errorf("Bevelled corner pixel is %v", dest.At(21, 11))
+	}
+
+	// A translucent zigzag that crosses itself is covered once, exactly as stroking its path
+	translucent, err := ImageSolid(color.RGBA{R: 128, A: 128})
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	zigzag := []PointF{{X: 2, Y: 2}, {X: 30, Y: 17.5}, {X: 30, Y: 3}, {X: 3, Y: 18}, {X: 12, Y: 2.5}}
+	var path Path
+	path.MoveTo(zigzag[0].X, zigzag[0].Y)
+	for _, pt := range zigzag[1:] {
+		path.LineTo(pt.X, pt.Y)
+	}
+	polyline, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 32, 20)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	stroked, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 32, 20)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	DrawPolyline(polyline, zigzag, 3, translucent)
+	StrokePath(stroked, &path, translucent, StrokeStyle{Width: 3, Join: JoinBevel, Cap: CapButt})
+	if err := compareSubImage(polyline, stroked, polyline.Bounds(), 0); err != nil {
+		t.Errorf("Polyline does not match the stroked path: %v", err)
+	}
+	for y := range 20 {
+		for x := range 32 {
+			if _, _, _, a := polyline.At(x, y).RGBA(); a>>8 > 128 {
+				t.Fatalf("Polyline pixel at (%d,%d) is %v, covered more than once", x, y, polyline.At(x, y))
+			}
+		}
+	}
+}
+
+func TestGlyphCache(t *testing.T) {
+	cache, err := NewGlyphCache()
+	if err != nil {
+		t.Fatalf("failed to create glyph cache: %v", err)
+	}
+	// A 4x6 bar whose origin is its bottom left corner, like a glyph sitting on the baseline
+	bar, err := imageCreate(PIXMAN_a8, 4, 6)
+	if err != nil {
+		t.Fatalf("failed to create glyph image: %v", err)
+	}
+	bar.Fill(image.Rect(0, 0, 4, 6), color.White)
+
+	if _, err := cache.Insert(1, 'l', 0, 6, bar); err == nil {
+		t.Errorf("Insert succeeded on a cache that isn't frozen")
+	}
+	cache.Freeze()
+	glyph, err := cache.Insert(1, 'l', 0, 6, bar)
+	if err != nil {
+		t.Fatalf("failed to insert glyph: %v", err)
+	}
+	if found, ok := cache.Lookup(1, 'l'); !ok || found != glyph {
+		t.Errorf("Lookup returned %x %v, expected %x", found, ok, glyph)
+	}
+	if _, ok := cache.Lookup(2, 'l'); ok {
+		t.Errorf("Lookup found a glyph under a different font key")
+	}
+
+	glyphs := []PixmanGlyph{{X: 2, Y: 10, Glyph: glyph}, {X: 10, Y: 10, Glyph: glyph}}
+	if extents := cache.Extents(glyphs); extents != image.Rect(2, 4, 14, 10) {
+		t.Errorf("Glyph extents are %v", extents)
+	}
+	if format := cache.MaskFormat(glyphs); format != PIXMAN_a8 {
+		t.Errorf("Glyph mask format is %s", format)
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	solid, err := ImageSolid(red)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 20, 20)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if err := CompositeGlyphs(PIXMAN_OP_OVER, solid, dest, cache, glyphs); err != nil {
+		t.Fatalf("failed to composite glyphs: %v", err)
+	}
+	// Glyphs partly or wholly outside the destination are clipped to it
+	offscreen := []PixmanGlyph{{X: 18, Y: 10, Glyph: glyph}, {X: 1 << 30, Y: 1 << 30, Glyph: glyph}}
+	if err := CompositeGlyphs(PIXMAN_OP_OVER, solid, dest, cache, offscreen); err != nil {
+		t.Fatalf("failed to composite glyphs: %v", err)
+	}
+	if err := CompositeGlyphs(PIXMAN_OP_OVER, solid, dest, cache, []PixmanGlyph{{X: 2, Y: 10}}); err == nil {
+		t.Errorf("CompositeGlyphs accepted a glyph without a handle")
+	}
+	cache.Thaw()
+	assertCoverage(t, dest, []image.Rectangle{image.Rect(2, 4, 6, 10), image.Rect(10, 4, 14, 10), image.Rect(18, 4, 20, 10)}, red)
+
+	cache.Remove(1, 'l')
+	if _, ok := cache.Lookup(1, 'l'); ok {
+		t.Errorf("Lookup found a removed glyph")
+	}
+}
+
+// buildPSF2 returns a PSF2 font of 8x8 glyphs, mapped through a unicode table to the given runes
+func buildPSF2(glyphs map[rune][8]byte) []byte {
+	var data []byte
+	var table []byte
+	for r, rows := range glyphs {
+		data = append(data, rows[:]...)
+		table = append(table, []byte(string(r))...)
+		table = append(table, 0xff)
+	}
+	header := []uint32{0x864ab572, 0, 32, 1, uint32(len(glyphs)), 8, 8, 8}
+	var buf bytes.Buffer
+	binary.Write(&buf, binary.LittleEndian, header)
+	buf.Write(data)
+	buf.Write(table)
+	return buf.Bytes()
+}
+
+func TestPSFText(t *testing.T) {
+	block := [8]byte{0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff, 0xff}
+	question := [8]byte{0xff, 0xff, 0xff, 0xff}
+	font, err := ParsePSF(bytes.NewReader(buildPSF2(map[rune][8]byte{'A': block, ' ': {}, '?': question})))
+	if err != nil {
+		t.Fatalf("failed to parse PSF font: %v", err)
+	}
+	if size := font.MeasureString("AA A", TextOptions{}); size != image.Pt(32, 8) {
+		t.Errorf("Unwrapped text size is %v", size)
+	}
+	if size := font.MeasureString("AA A", TextOptions{MaxWidth: 16, LineSpacing: 2}); size != image.Pt(16, 18) {
+		t.Errorf("Wrapped text size is %v", size)
+	}
+	if size := font.MeasureString("AAAAA", TextOptions{MaxWidth: 16}); size != image.Pt(16, 24) {
+		t.Errorf("Broken word size is %v", size)
+	}
+	// Each invalid byte is one fallback glyph, however RuneError encodes
+	if size := font.MeasureString("\xff\xff\xff\xff\xff", TextOptions{MaxWidth: 16}); size != image.Pt(16, 24) {
+		t.Errorf("Broken invalid UTF-8 size is %v", size)
+	}
+
+	// Header sizes whose products overflow must be rejected rather than wrapping around
+	for _, header := range [][]uint32{
+		{0x864ab572, 0, 32, 0, 0xffffffff, 0x80000000, 8, 8},
+		{0x864ab572, 0, 32, 0, 2, 8, 0xffffffff, 0xffffffff},
+		{0x864ab572, 0, 0xffffffff, 0, 1, 8, 8, 8},
+		{0x864ab572, 0, 32, 0, 0x20000000, 8, 8, 8},
+	} {
+		var buf bytes.Buffer
+		binary.Write(&buf, binary.LittleEndian, header)
+		buf.Write(make([]byte, 16))
+		if _, err := ParsePSF(&buf); err == nil {
+			t.Errorf("PSF2 header %#x was accepted", header)
+		}
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	solid, err := ImageSolid(red)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	tests := []struct {
+		name    string
+		text    string
+		opts    TextOptions
+		covered []image.Rectangle
+	}{
+		{"plain", "A A", TextOptions{}, []image.Rectangle{image.Rect(1, 2, 9, 10), image.Rect(17, 2, 25, 10)}},
+		{"missing", "B", TextOptions{}, []image.Rectangle{image.Rect(1, 2, 9, 6)}},
+		{"wrapped", "A A", TextOptions{MaxWidth: 16, LineSpacing: 1}, []image.Rectangle{image.Rect(1, 2, 9, 10), image.Rect(1, 11, 9, 19)}},
+		{"right", "A", TextOptions{MaxWidth: 24, Align: AlignRight}, []image.Rectangle{image.Rect(17, 2, 25, 10)}},
+		{"centred", "A\nAAA", TextOptions{Align: AlignCenter}, []image.Rectangle{image.Rect(9, 2, 17, 10), image.Rect(1, 10, 25, 18)}},
+	}
+	for _, test := range tests {
+		t.Run(test.name, func(t *testing.T) {
+			dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 32, 20)))
+			if err != nil {
+				t.Fatalf("failed to create Pixman image: %v", err)
+			}
+			if err := font.DrawString(dest, 1, 2, test.text, solid, test.opts); err != nil {
+				t.Fatalf("failed to draw text: %v", err)
+			}
+			assertCoverage(t, dest, test.covered, red)
+		})
+	}
+}
+
+func TestBDFText(t *testing.T) {
+	bdf := `STARTFONT 2.1
+FONT test
+SIZE 6 75 75
+FONTBOUNDINGBOX 4 6 0 -2
+STARTPROPERTIES 2
+FONT_ASCENT 4
+FONT_DESCENT 2
+ENDPROPERTIES
+CHARS 2
+STARTCHAR x
+ENCODING 120
+SWIDTH 500 0
+DWIDTH 4 0
+BBX 2 3 1 -1
+BITMAP
+C0
+40
+C0
+ENDCHAR
+STARTCHAR space
+ENCODING 32
+SWIDTH 500 0
+DWIDTH 4 0
+BBX 0 0 0 0
+BITMAP
+ENDCHAR
+ENDFONT
+`
+	path := t.TempDir() + "/test.bdf"
+	if err := os.WriteFile(path, []byte(bdf), 0o644); err != nil {
+		t.Fatalf("failed to write font: %v", err)
+	}
+	font, err := LoadFont(path)
+	if err != nil {
+		t.Fatalf("failed to load BDF font: %v", err)
+	}
+	if font.Ascent() != 4 || font.Descent() != 2 || font.LineHeight() != 6 {
+		t.Errorf("Font metrics are %d/%d/%d", font.Ascent(), font.Descent(), font.LineHeight())
+	}
+	if size := font.MeasureString("x x", TextOptions{}); size != image.Pt(12, 6) {
+		t.Errorf("Text size is %v", size)
+	}
+
+	red := color.RGBA{R: 255, A: 255}
+	solid, err := ImageSolid(red)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rect(0, 0, 12, 6)))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if err := font.DrawString(dest, 0, 0, "x x", solid, TextOptions{}); err != nil {
+		t.Fatalf("failed to draw text: %v", err)
+	}
+	// Each x sits one pixel right of the pen, with its bottom row one pixel below the baseline
+	set := map[image.Point]bool{
+		{1, 2}: true, {2, 2}: true, {2, 3}: true, {1, 4}: true, {2, 4}: true,
+		{9, 2}: true, {10, 2}: true, {10, 3}: true, {9, 4}: true, {10, 4}: true,
+	}
+	for y := range 6 {
+		for x := range 12 {
+			expected := color.Color(color.Transparent)
+			if set[image.Pt(x, y)] {
+				expected = red
+			}
+			if !colorMatch(dest.At(x, y), expected, 0) {
+				t.Errorf("Text pixel at (%d,%d) is %v, expected %v", x, y, dest.At(x, y), expected)
+			}
+		}
+	}
+
+	// Glyph sizes are untrusted, so negative or huge ones must be rejected before a bitmap is allocated for them
+	for _, bbx := range []string{"BBX -1 5 0 0", "BBX 5 -1 0 0", "BBX 100000 100000 0 0"} {
+		malformed := "STARTFONT 2.1\nFONTBOUNDINGBOX 4 6 0 -2\nCHARS 1\nSTARTCHAR x\nENCODING 120\nDWIDTH 4 0\n" +
+			bbx + "\nBITMAP\nC0\nENDCHAR\nENDFONT\n"
+		if _, err := ParseBDF(strings.NewReader(malformed)); err == nil {
+			t.Errorf("BDF glyph with %q was accepted", bbx)
+		}
+	}
+}
+
+func TestComponentAlpha(t *testing.T) {
+	size := image.Point{X: 4, Y: 4}
+	white, err := ImageSolid(color.White)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	// Full coverage on the red subpixel, none on green and half on blue
+	maskImg := image.NewRGBA(image.Rectangle{Max: size})
+	draw.Draw(maskImg, maskImg.Bounds(), &image.Uniform{C: color.RGBA{R: 255, G: 0, B: 128, A: 255}}, image.Point{}, draw.Src)
+	mask, err := ImageFromImage(maskImg)
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	if mask.ComponentAlpha() {
+		t.Errorf("New image has component alpha set")
+	}
+
+	for _, componentAlpha := range []bool{false, true} {
+		mask.SetComponentAlpha(componentAlpha)
+		if mask.ComponentAlpha() != componentAlpha {
+			t.Errorf("ComponentAlpha is %v after setting %v", mask.ComponentAlpha(), componentAlpha)
+		}
+		destImg := image.NewRGBA(image.Rectangle{Max: size})
+		draw.Draw(destImg, destImg.Bounds(), &image.Uniform{C: color.Black}, image.Point{}, draw.Src)
+		dest, err := ImageFromImage(destImg)
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		dest.CompositeOp(PIXMAN_OP_OVER, white, mask, image.Point{}, image.Point{}, image.Point{}, size)
+		// Without component alpha the mask's alpha covers every channel fully
+		expected := color.RGBA{R: 255, G: 255, B: 255, A: 255}
+		if componentAlpha {
+			expected = color.RGBA{R: 255, G: 0, B: 128, A: 255}
+		}
+		if err := compareSubImage(dest, &image.Uniform{C: expected}, dest.Bounds(), 1); err != nil {
+			t.Errorf("Component alpha %v: %v", componentAlpha, err)
+		}
+	}
+}
+
+func TestAlphaMap(t *testing.T) {
+	// An opaque-format colour plane, with colours already premultiplied by the separate alpha plane
+	colour := make([]byte, 4*4)
+	for i := range 4 {
+		colour[4*i] = 100
+	}
+	img, err := ImageFromBits(formatRGBX, 4, 1, colour, 16)
+	if err != nil {
+		t.Fatalf("failed to create colour image: %v", err)
+	}
+	alpha, err := ImageFromBits(PIXMAN_a8, 4, 1, []byte{255, 200, 100, 255}, 4)
+	if err != nil {
+		t.Fatalf("failed to create alpha image: %v", err)
+	}
+	if err := img.SetAlphaMap(img, image.Point{}); err == nil {
+		t.Errorf("Image accepted itself as an alpha map")
+	}
+	if err := img.SetAlphaMap(alpha, image.Point{}); err != nil {
+		t.Fatalf("failed to set alpha map: %v", err)
+	}
+
+	size := image.Point{X: 4, Y: 1}
+	dest, err := ImageFromImage(image.NewRGBA(image.Rectangle{Max: size}))
+	if err != nil {
+		t.Fatalf("failed to create Pixman image: %v", err)
+	}
+	dest.CompositeOp(PIXMAN_OP_SRC, img, nil, image.Point{}, image.Point{}, image.Point{}, size)
+	for x, a := range []uint8{255, 200, 100, 255} {
+		expected := color.RGBA{R: 100, A: a}
+		if !colorMatch(dest.At(x, 0), expected, 0) {
+			t.Errorf("Pixel %d with alpha map is %v, expected %v", x, dest.At(x, 0), expected)
+		}
+	}
+
+	// Without the alpha map the colour plane is opaque again
+	if err := img.SetAlphaMap(nil, image.Point{}); err != nil {
+		t.Fatalf("failed to clear alpha map: %v", err)
+	}
+	dest.CompositeOp(PIXMAN_OP_SRC, img, nil, image.Point{}, image.Point{}, image.Point{}, size)
+	if err := compareSubImage(dest, &image.Uniform{C: color.RGBA{R: 100, A: 255}}, dest.Bounds(), 0); err != nil {
+		t.Errorf("Cleared alpha map: %v", err)
+	}
+}
+
+// referenceFactors returns the Porter-Duff source and destination factors of a disjoint or conjoint operator,
+// from the formulas in the X Render specification
+func referenceFactors(op PixmanOperation, as, ad float64) (float64, float64) {
+	conjoint := op >= PIXMAN_OP_CONJOINT_CLEAR
+	// Fractions of the source and destination that lie outside, or inside, the other
+	outA, outB := math.Min(1, (1-ad)/as), math.Min(1, (1-as)/ad)
+	inA, inB := math.Max(1-(1-ad)/as, 0), math.Max(1-(1-as)/ad, 0)
+	if conjoint {
+		outA, outB = math.Max(1-ad/as, 0), math.Max(1-as/ad, 0)
+		inA, inB = math.Min(1, ad/as), math.Min(1, as/ad)
+	}
+	switch op & 0x0f {
+	case PIXMAN_OP_CLEAR:
+		return 0, 0
+	case PIXMAN_OP_SRC:
+		return 1, 0
+	case PIXMAN_OP_DST:
+		return 0, 1
+	case PIXMAN_OP_OVER:
+		return 1, outB
+	case PIXMAN_OP_OVER_REVERSE:
+		return outA, 1
+	case PIXMAN_OP_IN:
+		return inA, 0
+	case PIXMAN_OP_IN_REVERSE:
+		return 0, inB
+	case PIXMAN_OP_OUT:
+		return outA, 0
+	case PIXMAN_OP_OUT_REVERSE:
+		return 0, outB
+	case PIXMAN_OP_ATOP:
+		return inA, outB
+	case PIXMAN_OP_ATOP_REVERSE:
+		return outA, inB
+	default:
+		return outA, outB
+	}
+}
+
+// referenceBlend applies a separable PDF blend mode to a source and backdrop channel, both unpremultiplied
+func referenceBlend(op PixmanOperation, cs, cb float64) float64 {
+	hardLight := func(cs, cb float64) float64 {
+		if cs <= 0.5 {
+			return cb * 2 * cs
+		}
+		s := 2*cs - 1
+		return cb + s - cb*s
+	}
+	switch op {
+	case PIXMAN_OP_MULTIPLY:
+		return cs * cb
+	case PIXMAN_OP_SCREEN:
+		return cs + cb - cs*cb
+	case PIXMAN_OP_OVERLAY:
+		return hardLight(cb, cs)
+	case PIXMAN_OP_DARKEN:
+		return math.Min(cs, cb)
+	case PIXMAN_OP_LIGHTEN:
+		return math.Max(cs, cb)
+	case PIXMAN_OP_COLOR_DODGE:
+		if cb == 0 {
+			return 0
+		}
+		if cs == 1 {
+			return 1
+		}
+		return math.Min(1, cb/(1-cs))
+	case PIXMAN_OP_COLOR_BURN:
+		if cb == 1 {
+			return 1
+		}
+		if cs == 0 {
+			return 0
+		}
+		return 1 - math.Min(1, (1-cb)/cs)
+	case PIXMAN_OP_HARD_LIGHT:
+		return hardLight(cs, cb)
+	case PIXMAN_OP_SOFT_LIGHT:
+		if cs <= 0.5 {
+			return cb - (1-2*cs)*cb*(1-cb)
+		}
+		d := math.Sqrt(cb)
+		if cb <= 0.25 {
+			d = ((16*cb-12)*cb + 4) * cb
+		}
+		return cb + (2*cs-1)*(d-cb)
+	case PIXMAN_OP_DIFFERENCE:
+		return math.Abs(cs - cb)
+	default:
+		return cs + cb - 2*cs*cb
+	}
+}
+
+// referenceBlendHSL applies a non-separable PDF blend mode to unpremultiplied source and backdrop colours
+func referenceBlendHSL(op PixmanOperation, cs, cb [3]float64) [3]float64 {
+	lum := func(c [3]float64) float64 {
+		return 0.3*c[0] + 0.59*c[1] + 0.11*c[2]
+	}
+	sat := func(c [3]float64) float64 {
+		return max(c[0], c[1], c[2]) - min(c[0], c[1], c[2])
+	}
+	setLum := func(c [3]float64, l float64) [3]float64 {
+		d := l - lum(c)
+		for i := range c {
+			c[i] += d
+		}
+		l = lum(c)
+		n, x := min(c[0], c[1], c[2]), max(c[0], c[1], c[2])
+		for i := range c {
+			if n < 0 {
+				c[i] = l + (c[i]-l)*l/(l-n)
+			}
+			if x > 1 {
+				c[i] = l + (c[i]-l)*(1-l)/(x-l)
+			}
+		}
+		return c
+	}
+	setSat := func(c [3]float64, s float64) [3]float64 {
+		n, x := min(c[0], c[1], c[2]), max(c[0], c[1], c[2])
+		var retval [3]float64
+		if x > n {
+			for i := range c {
+				retval[i] = (c[i] - n) * s / (x - n)
+			}
+		}
+		return retval
+	}
+	switch op {
+	case PIXMAN_OP_HSL_HUE:
+		return setLum(setSat(cs, sat(cb)), lum(cb))
+	case PIXMAN_OP_HSL_SATURATION:
+		return setLum(setSat(cb, sat(cs)), lum(cb))
+	case PIXMAN_OP_HSL_COLOR:
+		return setLum(cs, lum(cb))
+	default:
+		return setLum(cb, lum(cs))
+	}
+}
+
+func TestBlendModes(t *testing.T) {
+	src := color.RGBA{R: 120, G: 60, B: 30, A: 153}
+	dst := color.RGBA{R: 35, G: 105, B: 154, A: 179}
+	solid, err := ImageSolid(src)
+	if err != nil {
+		t.Fatalf("failed to create solid image: %v", err)
+	}
+	s := [4]float64{float64(src.R) / 255, float64(src.G) / 255, float64(src.B) / 255, float64(src.A) / 255}
+	d := [4]float64{float64(dst.R) / 255, float64(dst.G) / 255, float64(dst.B) / 255, float64(dst.A) / 255}
+	as, ad := s[3], d[3]
+
+	var ops []PixmanOperation
+	for op := PIXMAN_OP_DISJOINT_CLEAR; op <= PIXMAN_OP_DISJOINT_XOR; op++ {
+		ops = append(ops, op, op+PIXMAN_OP_CONJOINT_CLEAR-PIXMAN_OP_DISJOINT_CLEAR)
+	}
+	for op := PIXMAN_OP_MULTIPLY; op <= PIXMAN_OP_HSL_LUMINOSITY; op++ {
+		ops = append(ops, op)
+	}
+	for _, op := range ops {
+		var expected [4]float64
+		switch {
+		case op < PIXMAN_OP_MULTIPLY:
+			fa, fb := referenceFactors(op, as, ad)
+			for i := range expected {
+				expected[i] = math.Min(1, s[i]*fa+d[i]*fb)
+			}
+		default:
+			// Blend modes composite the blended colour over the backdrop, as premultiplied values
+			var blended [3]float64
+			cs := [3]float64{s[0] / as, s[1] / as, s[2] / as}
+			cb := [3]float64{d[0] / ad, d[1] / ad, d[2] / ad}
+			if op >= PIXMAN_OP_HSL_HUE {
+				blended = referenceBlendHSL(op, cs, cb)
+			} else {
+				for i := range blended {
+					blended[i] = referenceBlend(op, cs[i], cb[i])
+				}
+			}
+			for i := range blended {
+				expected[i] = (1-as)*d[i] + (1-ad)*s[i] + as*ad*blended[i]
+			}
+			expected[3] = as + ad - as*ad
+		}
+		want := color.RGBA{
+			R: uint8(math.Round(expected[0] * 255)),
+			G: uint8(math.Round(expected[1] * 255)),
+			B: uint8(math.Round(expected[2] * 255)),
+			A: uint8(math.Round(expected[3] * 255)),
+		}
+
+		destImg := image.NewRGBA(image.Rect(0, 0, 1, 1))
+		destImg.SetRGBA(0, 0, dst)
+		dest, err := ImageFromImage(destImg)
+		if err != nil {
+			t.Fatalf("failed to create Pixman image: %v", err)
+		}
+		dest.CompositeOp(op, solid, nil, image.Point{}, image.Point{}, image.Point{}, image.Pt(1, 1))
+		if !colorMatch(dest.At(0, 0), want, 3) {
+			t.Errorf("%s gave %v, expected %v", op, dest.At(0, 0), want)
+		}
+	}
+
+	if name := PIXMAN_OP_SOFT_LIGHT.String(); name != "PIXMAN_OP_SOFT_LIGHT" {
+		t.Errorf("Blend mode name is %q", name)
+	}
+}
+
+func TestFormatMetadata(t *testing.T) {
+	tests := []struct {
+		format     PixmanFormatCode
+		bpp        int
+		formatType PixmanFormatType
+		a, r, g, b int
+		color      bool
+	}{
+		{PIXMAN_rgba_float, 128, PIXMAN_TYPE_RGBA_FLOAT, 32, 32, 32, 32, true},
+		{PIXMAN_rgb_float, 96, PIXMAN_TYPE_RGBA_FLOAT, 0, 32, 32, 32, true},
+		{PIXMAN_a16b16g16r16, 64, PIXMAN_TYPE_ABGR, 16, 16, 16, 16, true},
+		{PIXMAN_a8r8g8b8, 32, PIXMAN_TYPE_ARGB, 8, 8, 8, 8, true},
+		{PIXMAN_r8g8b8x8, 32, PIXMAN_TYPE_RGBA, 0, 8, 8, 8, true},
+		{PIXMAN_a2b10g10r10, 32, PIXMAN_TYPE_ABGR, 2, 10, 10, 10, true},
+		{PIXMAN_x14r6g6b6, 32, PIXMAN_TYPE_ARGB, 0, 6, 6, 6, true},
+		{PIXMAN_a8r8g8b8_sRGB, 32, PIXMAN_TYPE_ARGB_SRGB, 8, 8, 8, 8, false},
+		{PIXMAN_r8g8b8, 24, PIXMAN_TYPE_ARGB, 0, 8, 8, 8, true},
+		{PIXMAN_r5g6b5, 16, PIXMAN_TYPE_ARGB, 0, 5, 6, 5, true},
+		{PIXMAN_a4b4g4r4, 16, PIXMAN_TYPE_ABGR, 4, 4, 4, 4, true},
+		{PIXMAN_r3g3b2, 8, PIXMAN_TYPE_ARGB, 0, 3, 3, 2, true},
+		{PIXMAN_a8, 8, PIXMAN_TYPE_A, 8, 0, 0, 0, false},
+		{PIXMAN_c8, 8, PIXMAN_TYPE_COLOR, 0, 0, 0, 0, false},
+		{PIXMAN_g4, 4, PIXMAN_TYPE_GRAY, 0, 0, 0, 0, false},
+		{PIXMAN_a1r1g1b1, 4, PIXMAN_TYPE_ARGB, 1, 1, 1, 1, true},
+		{PIXMAN_a1, 1, PIXMAN_TYPE_A, 1, 0, 0, 0, false},
+		{PIXMAN_yuy2, 16, PIXMAN_TYPE_YUY2, 0, 0, 0, 0, false},
+		{PIXMAN_yv12, 12, PIXMAN_TYPE_YV12, 0, 0, 0, 0, false},
+	}
+	for _, test := range tests {
+		f := test.format
+		if f.BPP() != test.bpp || f.Type() != test.formatType {
+			t.Errorf("%s has bpp %d and type %s, expected %d and %s", f, f.BPP(), f.Type(), test.bpp, test.formatType)
+		}
+		if f.A() != test.a || f.R() != test.r || f.G() != test.g || f.B() != test.b {
+			t.Errorf("%s has channels a%dr%dg%db%d, expected a%dr%dg%db%d", f, f.A(), f.R(), f.G(), f.B(), test.a, test.r, test.g, test.b)
+		}
+		if f.Depth() != test.a+test.r+test.g+test.b {
+			t.Errorf("%s has depth %d", f, f.Depth())
+		}
+		if f.HasAlpha() != (test.a > 0) || f.IsColor() != test.color {
+			t.Errorf("%s has alpha %v and colour %v", f, f.HasAlpha(), f.IsColor())
+		}
+		if strings.HasPrefix(f.String(), "Unknown") {
+			t.Errorf("Format %x has no name", uint32(f))
+		}
+	}
+	if PIXMAN_x4g4 != PIXMAN_g8 || PIXMAN_x4g4.String() != "PIXMAN_g8" {
+		t.Errorf("x4g4 is %s", PIXMAN_x4g4)
+	}
+}
+
+func TestPixelCodec(t *testing.T) {
+	tests := []struct {
+		format PixmanFormatCode
+		col    color.Color
+		pixel  uint32
+	}{
+		{PIXMAN_a8r8g8b8, color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0x78}, 0x78123456},
+		{PIXMAN_x8b8g8r8, color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0xff}, 0xff563412},
+		{PIXMAN_b8g8r8a8, color.RGBA{R: 1, G: 2, B: 3, A: 4}, 0x03020104},
+		{PIXMAN_r8g8b8, color.RGBA{R: 1, G: 2, B: 3, A: 0xff}, 0x010203},
+		{PIXMAN_r5g6b5, color.RGBA{R: 0xff, G: 0x82, B: 0x08, A: 0xff}, 0xfc01},
+		{PIXMAN_a1r5g5b5, color.RGBA{R: 0x84, G: 0, B: 0xff, A: 0xff}, 0xc01f},
+		{PIXMAN_a4b4g4r4, color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0x44}, 0x4321},
+		{PIXMAN_a2r10g10b10, color.RGBA64{R: 0xffff, G: 0x8020, B: 0, A: 0xffff}, 0xfff80000},
+		{PIXMAN_r3g3b2, color.RGBA{R: 0xff, G: 0x24, B: 0x55, A: 0xff}, 0xe5},
+		{PIXMAN_a8, color.Alpha{A: 0x9a}, 0x9a},
+	}
+	for _, test := range tests {
+		img, err := imageCreate(test.format, 1, 1)
+		if err != nil {
+			t.Fatalf("failed to create %s image: %v", test.format, err)
+		}
+		img.Set(0, 0, test.col)
+		codec := pixelCodecs[test.format]
+		if pixel := codec.load(img.rawData); pixel != test.pixel {
+			t.Errorf("%s stored %v as %x, expected %x", test.format, test.col, pixel, test.pixel)
+		}
+		if !colorMatch(img.At(0, 0), test.col, 0) {
+			t.Errorf("%s read back %v, expected %v", test.format, img.At(0, 0), test.col)
+		}
+	}
+}
+
+func TestPixelCodecRoundTrip(t *testing.T) {
+	for _, format := range codecFormats {
+		codec, ok := pixelCodecs[format]
+		if !ok {
+			t.Errorf("%s has no pixel codec", format)
+			continue
+		}
+		// Every pixel value of narrow formats, including those with a colour channel greater than alpha, which
+		// pixman can store too. Wider formats sweep each colour channel in turn at full alpha, and alpha with the
+		// colour derived from it, so that every pixel is a valid premultiplied colour.
+		var pixels []uint32
+		if format.BPP() <= 16 {
+			for p := range uint32(1) << format.BPP() {
+				pixels = append(pixels, p)
+			}
+		} else {
+			alphaMask := uint32(1<<codec.a.bits-1) << codec.a.shift
+			for _, c := range []channel{codec.r, codec.g, codec.b} {
+				for v := range uint32(1) << c.bits {
+					pixels = append(pixels, v<<c.shift|0x5a5a5a5a&^((1<<c.bits-1)<<c.shift)&^alphaMask|alphaMask)
+				}
+			}
+			for v := range uint32(1) << codec.a.bits {
+				a := codec.a.expand(v << codec.a.shift)
+				pixels = append(pixels, codec.a.quantize(a)|codec.r.quantize(a)|codec.g.quantize(a/2)|codec.b.quantize(a/3))
+			}
+		}
+		src, err := imageCreate(format, len(pixels), 1)
+		if err != nil {
+			t.Fatalf("failed to create %s image: %v", format, err)
+		}
+		dst, err := imageCreate(format, len(pixels), 1)
+		if err != nil {
+			t.Fatalf("failed to create %s image: %v", format, err)
+		}
+		mask := uint32(1)<<format.BPP() - 1
+		for x, p := range pixels {
+			codec.store(src.rawData[x*codec.bytes:], p&mask)
+			dst.Set(x, 0, src.At(x, 0))
+			expected := (p | codec.padding) & mask
+			if got := codec.load(dst.rawData[x*codec.bytes:]); got != expected {
+				t.Fatalf("%s pixel %x read as %v and stored as %x", format, p&mask, src.At(x, 0), got)
+			}
+		}
+
+		// Pixman quantizes colours the same way when filling, apart from padding bits
+		if max(format.A(), format.R(), format.G(), format.B()) > 8 {
+			continue
+		}
+		// The last colour's alpha truncates to 0 in narrow formats, while its colour channels don't
+		for _, col := range []color.Color{color.RGBA{R: 0x12, G: 0x9a, B: 0xfe, A: 0xff}, color.RGBA{R: 0x40, G: 0x21, B: 0x7f, A: 0x80}, color.RGBA{R: 0x44, B: 0x7f, A: 0x7f}} {
+			img, err := imageCreate(format, 1, 1)
+			if err != nil {
+				t.Fatalf("failed to create %s image: %v", format, err)
+			}
+			img.Fill(img.Bounds(), col)
+			got := codec.load(img.rawData) &^ codec.padding
+			if expected := codec.encode(col) &^ codec.padding; got != expected {
+				t.Errorf("%s filled %v as %x, codec encodes %x", format, col, got, expected)
+			}
+		}
+	}
+}
+
+func TestColorModels(t *testing.T) {
+	tests := []struct {
+		model    color.Model
+		col      color.Color
+		expected color.Color
+	}{
+		{RGB565Model, color.RGBA{R: 0xff, G: 0x82, B: 0x0b, A: 0xff}, RGB565(0xfc01)},
+		// Alpha truncates to 0 but the colour channels keep their own bits, as pixman stores them
+		{ARGB1555Model, color.RGBA{R: 0x44, G: 0, B: 0x7f, A: 0x7f}, ARGB1555(0x200f)},
+		{ARGB1555Model, color.White, ARGB1555(0xffff)},
+		{ARGB4444Model, color.RGBA{R: 0x11, G: 0x22, B: 0x33, A: 0x44}, ARGB4444(0x4123)},
+		{A8Model, color.Alpha{A: 0x9a}, A8(0x9a)},
+		{A8Model, color.RGBA{R: 0x20, A: 0x40}, A8(0x40)},
+		{ARGB2101010Model, color.RGBA64{R: 0xffff, G: 0x8020, B: 0, A: 0xffff}, ARGB2101010(0xfff80000)},
+		// Alpha truncates to 0x5555, which limits red
+		{ARGB2101010Model, color.RGBA64{R: 0x7000, A: 0x7fff}, ARGB2101010(0x55500000)},
+	}
+	for _, test := range tests {
+		if got := test.model.Convert(test.col); got != test.expected {
+			t.Errorf("Converting %v gave %v, expected %v", test.col, got, test.expected)
+		}
+	}
+	// Every converted colour must still be premultiplied, with no channel greater than alpha
+	for _, model := range []color.Model{RGB565Model, ARGB1555Model, ARGB4444Model, A8Model, ARGB2101010Model} {
+		for a := 0; a <= 0xffff; a += 0x0101 {
+			for c := 0; c <= a; c += 0x1111 {
+				col := model.Convert(color.RGBA64{R: uint16(c), G: uint16(c / 2), B: uint16(a), A: uint16(a)})
+				if r, g, b, a := col.RGBA(); r > a || g > a || b > a {
+					t.Fatalf("Converted colour %#v is not premultiplied", col)
+				}
+			}
+		}
+	}
+	r, g, b, a := RGB565(0xfc01).RGBA()
+	if r != 0xffff || g != 0x8208 || b != 0x0842 || a != 0xffff {
+		t.Errorf("RGB565 expanded to %x %x %x %x", r, g, b, a)
+	}
+
+	formats := []struct {
+		format PixmanFormatCode
+		model  color.Model
+	}{
+		{PIXMAN_r5g6b5, RGB565Model},
+		{PIXMAN_b5g6r5, RGB565Model},
+		{PIXMAN_a1r5g5b5, ARGB1555Model},
+		{PIXMAN_a4b4g4r4, ARGB4444Model},
+		{PIXMAN_a8, A8Model},
+		{PIXMAN_a2b10g10r10, ARGB2101010Model},
+		{PIXMAN_a8r8g8b8, color.RGBAModel},
+	}
+	for _, test := range formats {
+		img, err := imageCreate(test.format, 4, 4)
+		if err != nil {
+			t.Fatalf("failed to create %s image: %v", test.format, err)
+		}
+		if img.ColorModel() != test.model {
+			t.Errorf("%s has the wrong colour model", test.format)
+		}
+		if got := img.At(-1, 0); got != color.Transparent {
+			t.Errorf("%s pixel outside the image is %#v, expected color.Transparent", test.format, got)
+		}
+		// Drawing through image/draw stores colours quantized by the model, and reads them back unchanged
+		col := color.RGBA{R: 0x9f, G: 0x5c, B: 0x31, A: 0xff}
+		draw.Draw(img, img.Bounds(), &image.Uniform{C: col}, image.Point{}, draw.Src)
+		if got, expected := img.At(1, 1), test.model.Convert(col); got != expected {
+			t.Errorf("%s pixel is %#v, expected %#v", test.format, got, expected)
+		}
+	}
+
+	// Whatever the format, a colour reads back exactly as the image's model converts it, even when the format
+	// has less alpha precision than the colour or none at all
+	translucent := []color.Color{
+		color.RGBA{R: 0x40, A: 0x40},
+		color.RGBA{R: 0x12, G: 0x34, B: 0x56, A: 0x80},
+		color.NRGBA{R: 0xff, G: 0x80, B: 0x10, A: 0x33},
+		color.RGBA64{R: 0x1234, G: 0x0567, B: 0x7fff, A: 0x8000},
+	}
+	for _, format := range codecFormats {
+		img, err := imageCreate(format, 1, 1)
+		if err != nil {
+			t.Fatalf("failed to create %s image: %v", format, err)
+		}
+		for _, col := range translucent {
+			img.Set(0, 0, col)
+			if got, expected := img.At(0, 0), img.ColorModel().Convert(col); got != expected {
+				t.Errorf("%s stored %v as %#v, but its model converts it to %#v", format, col, got, expected)
+			}
+		}
+	}
+
+	// Formats without a pixel codec read as transparent in their model
+	img, err := imageCreate(PIXMAN_a4, 4, 4)
+	if err != nil {
+		t.Fatalf("failed to create %s image: %v", PIXMAN_a4, err)
+	}
+	if got := img.At(1, 1); img.ColorModel() != color.RGBA64Model || got != color.RGBA64Model.Convert(color.Transparent) {
+		t.Errorf("%s pixel is %#v in %v", PIXMAN_a4, got, img.ColorModel())
+	}
+}
@@ -0,0 +1,155 @@
+package pixman
+
+import (
+	"fmt"
+	"image"
+	"runtime"
+	"unsafe"
+)
+
+// Region is a set of pixels described by non-overlapping rectangles, backed by pixman_region32_t
+type Region struct {
+	region *PixmanRegion32
+}
+
+// newRegion returns an empty region, which is released once the Region is garbage collected
+func newRegion() *Region {
+	retval := &Region{region: &PixmanRegion32{}}
+	Region32Init(retval.region)
+	runtime.AddCleanup(retval, func(raw *PixmanRegion32) {
+		Region32Fini(raw)
+	}, retval.region)
+	return retval
+}
+
+func boxFromRect(r image.Rectangle) PixmanBox32 {
+	r = r.Canon()
+	return PixmanBox32{X1: int32(r.Min.X), Y1: int32(r.Min.Y), X2: int32(r.Max.X), Y2: int32(r.Max.Y)}
+}
+
+func rectFromBox(b PixmanBox32) image.Rectangle {
+	return image.Rect(int(b.X1), int(b.Y1), int(b.X2), int(b.Y2))
+}
+
+// RegionFromRects creates a region covering the union of rects. Empty rectangles are ignored.
+func RegionFromRects(rects ...image.Rectangle) (*Region, error) {
+	retval := newRegion()
+	if len(rects) == 0 {
+		return retval, nil
+	}
+	boxes := make([]PixmanBox32, len(rects))
+	for i, r := range rects {
+		boxes[i] = boxFromRect(r)
+	}
+	if !Region32InitRects(retval.region, &boxes[0], int32(len(boxes))) {
+		return nil, fmt.Errorf("failed to create region from %d rectangles", len(rects))
+	}
+	return retval, nil
+}
+
+// Union returns a new region containing the pixels in either r or other
+func (r *Region) Union(other *Region) (*Region, error) {
+	retval := newRegion()
+	ok := Region32Union(retval.region, r.region, other.region)
+	runtime.KeepAlive(r)
+	runtime.KeepAlive(other)
+	if !ok {
+		return nil, fmt.Errorf("failed to compute region union")
+	}
+	return retval, nil
+}
+
+// Intersect returns a new region containing the pixels in both r and other
+func (r *Region) Intersect(other *Region) (*Region, error) {
+	retval := newRegion()
+	ok := Region32Intersect(retval.region, r.region, other.region)
+	runtime.KeepAlive(r)
+	runtime.KeepAlive(other)
+	if !ok {
+		return nil, fmt.Errorf("failed to compute region intersection")
+	}
+	return retval, nil
+}
+
+// Subtract returns a new region containing the pixels in r that are not in other
+func (r *Region) Subtract(other *Region) (*Region, error) {
+	retval := newRegion()
+	ok := Region32Subtract(retval.region, r.region, other.region)
+	runtime.KeepAlive(r)
+	runtime.KeepAlive(other)
+	if !ok {
+		return nil, fmt.Errorf("failed to compute region subtraction")
+	}
+	return retval, nil
+}
+
+// Inverse returns a new region containing the pixels within bounds that are not in r
+func (r *Region) Inverse(bounds image.Rectangle) (*Region, error) {
+	retval := newRegion()
+	box := boxFromRect(bounds)
+	ok := Region32Inverse(retval.region, r.region, &box)
+	runtime.KeepAlive(r)
+	if !ok {
+		return nil, fmt.Errorf("failed to compute region inverse within %v", bounds)
+	}
+	return retval, nil
+}
+
+// Translate moves the region by (dx, dy) in place
+func (r *Region) Translate(dx, dy int) {
+	Region32Translate(r.region, int32(dx), int32(dy))
+	runtime.KeepAlive(r)
+}
+
+// ContainsPoint reports whether the pixel at p is within the region
+func (r *Region) ContainsPoint(p image.Point) bool {
+	defer runtime.KeepAlive(r)
+	return Region32ContainsPoint(r.region, int32(p.X), int32(p.Y), nil)
+}
+
+// ContainsRect reports whether rect is entirely inside, partially inside or outside the region
+func (r *Region) ContainsRect(rect image.Rectangle) PixmanRegionOverlap {
+	defer runtime.KeepAlive(r)
+	box := boxFromRect(rect)
+	return Region32ContainsRectangle(r.region, &box)
+}
+
+// Extents returns the smallest rectangle enclosing the region
+func (r *Region) Extents() image.Rectangle {
+	// The extents are stored inside the region, so copy them before the region can be released
+	defer runtime.KeepAlive(r)
+	return rectFromBox(*Region32Extents(r.region))
+}
+
+// Rects returns the non-overlapping rectangles that make up the region, in y-x banded order
+func (r *Region) Rects() []image.Rectangle {
+	// The rectangles are stored inside the region, so copy them before the region can be released
+	defer runtime.KeepAlive(r)
+	var nRects int32
+	boxes := Region32Rectangles(r.region, &nRects)
+	if boxes == nil || nRects <= 0 {
+		return nil
+	}
+	retval := make([]image.Rectangle, nRects)
+	for i, box := range unsafe.Slice(boxes, nRects) {
+		retval[i] = rectFromBox(box)
+	}
+	return retval
+}
+
+// Equal reports whether r and other contain exactly the same pixels
+func (r *Region) Equal(other *Region) bool {
+	defer runtime.KeepAlive(other)
+	defer runtime.KeepAlive(r)
+	return Region32Equal(r.region, other.region)
+}
+
+// Empty reports whether the region contains no pixels
+func (r *Region) Empty() bool {
+	defer runtime.KeepAlive(r)
+	return !Region32NotEmpty(r.region)
+}
+
+func (r *Region) String() string {
+	return fmt.Sprintf("Region%v", r.Rects())
+}
@@ -0,0 +1,65 @@
+package pixman
+
+import "math"
+
+// Control point distance for approximating a quarter circle of radius 1 with a cubic bezier
+var circleKappa = 4 * (math.Sqrt2 - 1) / 3
+
+// Ellipse adds a closed ellipse centred on (cx, cy) with radii rx and ry as a new subpath
+func (p *Path) Ellipse(cx, cy, rx, ry float64) {
+	kx, ky := rx*circleKappa, ry*circleKappa
+	p.MoveTo(cx+rx, cy)
+	p.CubicTo(cx+rx, cy+ky, cx+kx, cy+ry, cx, cy+ry)
+	p.CubicTo(cx-kx, cy+ry, cx-rx, cy+ky, cx-rx, cy)
+	p.CubicTo(cx-rx, cy-ky, cx-kx, cy-ry, cx, cy-ry)
+	p.CubicTo(cx+kx, cy-ry, cx+rx, cy-ky, cx+rx, cy)
+	p.Close()
+}
+
+// RoundedRect adds a closed rectangle with its top left corner at (x, y) and corners rounded by radius as a new subpath.
+// The radius is limited to half of the shorter side.
+func (p *Path) RoundedRect(x, y, width, height, radius float64) {
+	radius = math.Max(0, math.Min(radius, math.Min(width, height)/2))
+	k := radius * circleKappa
+	right, bottom := x+width, y+height
+	p.MoveTo(x+radius, y)
+	p.LineTo(right-radius, y)
+	p.CubicTo(right-radius+k, y, right, y+radius-k, right, y+radius)
+	p.LineTo(right, bottom-radius)
+	p.CubicTo(right, bottom-radius+k, right-radius+k, bottom, right-radius, bottom)
+	p.LineTo(x+radius, bottom)
+	p.CubicTo(x+radius-k, bottom, x, bottom-radius+k, x, bottom-radius)
+	p.LineTo(x, y+radius)
+	p.CubicTo(x, y+radius-k, x+radius-k, y, x+radius, y)
+	p.Close()
+}
+
+// FillRoundedRect composites src onto this image using op, within an anti-aliased rectangle whose top left
+// corner is at (x, y) and whose corners are rounded by radius. Pixels outside the shape are left unchanged,
+// even for operators such as PIXMAN_OP_SRC that pixman would otherwise apply to the whole image.
+func (i *Image) FillRoundedRect(op PixmanOperation, src *Image, x, y, width, height, radius float64) error {
+	var path Path
+	path.RoundedRect(x, y, width, height, radius)
+	return fillPath(op, i, &path, src, FillRuleNonZero)
+}
+
+// FillEllipse composites src onto this image using op, within an anti-aliased ellipse centred on (cx, cy) with
+// radii rx and ry. Pixels outside the ellipse are left unchanged, whatever the operator.
+func (i *Image) FillEllipse(op PixmanOperation, src *Image, cx, cy, rx, ry float64) error {
+	var path Path
+	path.Ellipse(cx, cy, rx, ry)
+	return fillPath(op, i, &path, src, FillRuleNonZero)
+}
+
+// FillCircle composites src onto this image using op, within an anti-aliased circle centred on (cx, cy)
+func (i *Image) FillCircle(op PixmanOperation, src *Image, cx, cy, radius float64) error {
+	return i.FillEllipse(op, src, cx, cy, radius, radius)
+}
+
+// StrokeEllipse composites src onto this image using op, along the outline of an ellipse centred on (cx, cy)
+// with radii rx and ry. The outline is width pixels wide, centred on the ellipse.
+func (i *Image) StrokeEllipse(op PixmanOperation, src *Image, cx, cy, rx, ry, width float64) error {
+	var path Path
+	path.Ellipse(cx, cy, rx, ry)
+	return strokePath(op, i, &path, src, StrokeStyle{Width: width, Join: JoinRound})
+}
@@ -0,0 +1,350 @@
+package pixman
+
+import (
+	"fmt"
+	"math"
+)
+
+// LineJoin determines how the outside corner between two stroked segments is drawn
+type LineJoin uint32
+
+const (
+	// JoinMiter extends the segment edges until they meet, falling back to a bevel beyond the miter limit
+	JoinMiter LineJoin = iota
+	// JoinRound rounds the corner with a circular arc
+	JoinRound
+	// JoinBevel cuts the corner off with a straight line
+	JoinBevel
+)
+
+func (j LineJoin) String() string {
+	switch j {
+	case JoinMiter:
+		return "JoinMiter"
+	case JoinRound:
+		return "JoinRound"
+	case JoinBevel:
+		return "JoinBevel"
+	default:
+		return fmt.Sprintf("Unknown LineJoin: %d", uint32(j))
+	}
+}
+
+// LineCap determines how the ends of an open stroke are drawn
+type LineCap uint32
+
+const (
+	// CapButt ends the stroke exactly at the end point
+	CapButt LineCap = iota
+	// CapRound adds a semicircle around the end point
+	CapRound
+	// CapSquare extends the stroke by half its width past the end point
+	CapSquare
+)
+
+func (c LineCap) String() string {
+	switch c {
+	case CapButt:
+		return "CapButt"
+	case CapRound:
+		return "CapRound"
+	case CapSquare:
+		return "CapSquare"
+	default:
+		return fmt.Sprintf("Unknown LineCap: %d", uint32(c))
+	}
+}
+
+// The miter limit used when StrokeStyle.MiterLimit is not set
+const defaultMiterLimit = 4
+
+// StrokeStyle describes the outline drawn along a path by StrokePath
+type StrokeStyle struct {
+	Width      float64
+	Join       LineJoin
+	Cap        LineCap
+	MiterLimit float64   // Longest allowed ratio of miter length to stroke width, defaults to 4
+	Dash       []float64 // Alternating lengths of dashes and gaps, or nil for a solid stroke
+	DashOffset float64   // Distance into the dash pattern at which the stroke starts
+}
+
+// StrokePath draws the outline of path into dst with src, according to style.
+// Coordinates are in dst space, and src is aligned with dst.
+func StrokePath(dst *Image, path *Path, src *Image, style StrokeStyle) {
+	FillPath(dst, path.strokeOutline(style), src, FillRuleNonZero)
+}
+
+// strokePath strokes path like StrokePath, but using op, which may be unbounded as for fillPath
+func strokePath(op PixmanOperation, dst *Image, path *Path, src *Image, style StrokeStyle) error {
+	return fillPath(op, dst, path.strokeOutline(style), src, FillRuleNonZero)
+}
+
+// strokeOutline converts the stroke of p into a path of polygons, which covers the stroke when filled with FillRuleNonZero.
+// Each segment, join and cap becomes its own polygon, all wound in the same direction so that overlaps do not cancel out.
+func (p *Path) strokeOutline(style StrokeStyle) *Path {
+	outline := &Path{}
+	if style.Width <= 0 {
+		return outline
+	}
+	s := stroker{
+		outline:    outline,
+		halfWidth:  style.Width / 2,
+		join:       style.Join,
+		lineCap:    style.Cap,
+		miterLimit: style.MiterLimit,
+	}
+	if s.miterLimit <= 0 {
+		s.miterLimit = defaultMiterLimit
+	}
+	for _, sp := range p.subpaths {
+		points := dedupPoints(sp.points, sp.closed)
+		if len(style.Dash) > 0 {
+			if sp.closed && len(points) > 1 {
+				points = append(points, points[0])
+			}
+			for _, dash := range dashPolyline(points, style.Dash, style.DashOffset) {
+				s.polyline(dedupPoints(dash, false), false)
+			}
+			continue
+		}
+		s.polyline(points, sp.closed)
+	}
+	return outline
+}
+
+// dedupPoints returns points without consecutive duplicates, including the closing segment of closed subpaths
+func dedupPoints(points []PointF, closed bool) []PointF {
+	retval := make([]PointF, 0, len(points))
+	for _, pt := range points {
+		if len(retval) == 0 || retval[len(retval)-1] != pt {
+			retval = append(retval, pt)
+		}
+	}
+	if closed {
+		for len(retval) > 1 && retval[len(retval)-1] == retval[0] {
+			retval = retval[:len(retval)-1]
+		}
+	}
+	return retval
+}
+
+// dashPolyline splits points into the "on" sections of the dash pattern
+func dashPolyline(points []PointF, dash []float64, offset float64) [][]PointF {
+	total := 0.0
+	for _, d := range dash {
+		if d < 0 {
+			return [][]PointF{points}
+		}
+		total += d
+	}
+	if total <= 0 || len(points) == 0 {
+		return [][]PointF{points}
+	}
+	if len(dash)%2 != 0 {
+		// An odd number of entries is repeated so that dashes and gaps alternate
+		dash = append(dash[:len(dash):len(dash)], dash...)
+		total *= 2
+	}
+
+	// Skip the offset into the pattern
+	idx := 0
+	remaining := dash[0]
+	offset = math.Mod(offset, total)
+	if offset < 0 {
+		offset += total
+	}
+	for offset > 0 {
+		if offset >= remaining {
+			offset -= remaining
+			idx = (idx + 1) % len(dash)
+			remaining = dash[idx]
+		} else {
+			remaining -= offset
+			offset = 0
+		}
+	}
+
+	var retval [][]PointF
+	var current []PointF
+	on := idx%2 == 0
+	if on {
+		current = []PointF{points[0]}
+	}
+	for i := 0; i+1 < len(points); i++ {
+		a, b := points[i], points[i+1]
+		length := math.Hypot(b.X-a.X, b.Y-a.Y)
+		pos := 0.0
+		for length-pos > remaining {
+			pos += remaining
+			pt := PointF{X: a.X + (b.X-a.X)*pos/length, Y: a.Y + (b.Y-a.Y)*pos/length}
+			if on {
+				retval = append(retval, append(current, pt))
+				current = nil
+			} else {
+				current = []PointF{pt}
+			}
+			on = !on
+			idx = (idx + 1) % len(dash)
+			remaining = dash[idx]
+		}
+		remaining -= length - pos
+		if on {
+			current = append(current, b)
+		}
+	}
+	if on && len(current) > 0 {
+		retval = append(retval, current)
+	}
+	return retval
+}
+
+type stroker struct {
+	outline    *Path
+	halfWidth  float64
+	join       LineJoin
+	lineCap    LineCap
+	miterLimit float64
+}
+
+// polygon adds a closed polygon to the outline, reversing it if needed so that every polygon has the same winding
+func (s *stroker) polygon(points ...PointF) {
+	area := 0.0
+	for i, a := range points {
+		b := points[(i+1)%len(points)]
+		area += a.X*b.Y - b.X*a.Y
+	}
+	if area < 0 {
+		for i, j := 0, len(points)-1; i < j; i, j = i+1, j-1 {
+			points[i], points[j] = points[j], points[i]
+		}
+	}
+	s.outline.MoveTo(points[0].X, points[0].Y)
+	for _, pt := range points[1:] {
+		s.outline.LineTo(pt.X, pt.Y)
+	}
+	s.outline.Close()
+}
+
+// arcSegments returns the number of line segments needed to approximate a full circle of the stroke's radius
+func (s *stroker) arcSegments() int {
+	step := 2 * math.Acos(math.Max(1-flattenTolerance/s.halfWidth, -1))
+	return max(int(math.Ceil(2*math.Pi/step)), 8)
+}
+
+// arc adds the pie slice around centre from angle start, sweeping by sweep radians
+func (s *stroker) arc(centre PointF, start, sweep float64) {
+	n := max(int(math.Ceil(float64(s.arcSegments())*math.Abs(sweep)/(2*math.Pi))), 1)
+	points := make([]PointF, 0, n+2)
+	points = append(points, centre)
+	for i := 0; i <= n; i++ {
+		angle := start + sweep*float64(i)/float64(n)
+		points = append(points, PointF{X: centre.X + s.halfWidth*math.Cos(angle), Y: centre.Y + s.halfWidth*math.Sin(angle)})
+	}
+	s.polygon(points...)
+}
+
+// unitNormal returns the unit vector perpendicular to the direction from a to b
+func unitNormal(a, b PointF) PointF {
+	length := math.Hypot(b.X-a.X, b.Y-a.Y)
+	return PointF{X: -(b.Y - a.Y) / length, Y: (b.X - a.X) / length}
+}
+
+func offset(pt, n PointF, distance float64) PointF {
+	return PointF{X: pt.X + n.X*distance, Y: pt.Y + n.Y*distance}
+}
+
+func (s *stroker) polyline(points []PointF, closed bool) {
+	hw := s.halfWidth
+	if len(points) == 1 {
+		// A zero length stroke is only visible through its caps
+		switch s.lineCap {
+		case CapRound:
+			s.arc(points[0], 0, 2*math.Pi)
+		case CapSquare:
+			pt := points[0]
+			s.polygon(PointF{X: pt.X - hw, Y: pt.Y - hw}, PointF{X: pt.X + hw, Y: pt.Y - hw}, PointF{X: pt.X + hw, Y: pt.Y + hw}, PointF{X: pt.X - hw, Y: pt.Y + hw})
+		}
+		return
+	}
+	if len(points) < 2 {
+		return
+	}
+	segments := len(points) - 1
+	if closed {
+		segments = len(points)
+	}
+	for i := range segments {
+		a, b := points[i], points[(i+1)%len(points)]
+		n := unitNormal(a, b)
+		s.polygon(offset(a, n, hw), offset(b, n, hw), offset(b, n, -hw), offset(a, n, -hw))
+	}
+
+	// Joins between segments, including the join at the start of a closed subpath
+	for i := range len(points) {
+		if !closed && (i == 0 || i == len(points)-1) {
+			continue
+		}
+		prev := points[(i+len(points)-1)%len(points)]
+		s.joinAt(prev, points[i], points[(i+1)%len(points)])
+	}
+
+	if !closed {
+		s.capAt(points[1], points[0])
+		s.capAt(points[len(points)-2], points[len(points)-1])
+	}
+}
+
+// joinAt fills the gap on the outside of the corner at v, between the segments prev-v and v-next
+func (s *stroker) joinAt(prev, v, next PointF) {
+	hw := s.halfWidth
+	n0 := unitNormal(prev, v)
+	n1 := unitNormal(v, next)
+	d0 := PointF{X: n0.Y, Y: -n0.X}
+	d1 := PointF{X: n1.Y, Y: -n1.X}
+	cross := d0.X*d1.Y - d0.Y*d1.X
+	dot := d0.X*d1.X + d0.Y*d1.Y
+	if math.Abs(cross) < 1e-9 && dot > 0 {
+		// Straight through, the segments already meet
+		return
+	}
+	// The outside of the corner is on the opposite side to the direction of the turn
+	side := 1.0
+	if cross > 0 {
+		side = -1
+	}
+	outer0 := offset(v, n0, side*hw)
+	outer1 := offset(v, n1, side*hw)
+
+	switch s.join {
+	case JoinRound:
+		start := math.Atan2(outer0.Y-v.Y, outer0.X-v.X)
+		end := math.Atan2(outer1.Y-v.Y, outer1.X-v.X)
+		sweep := math.Remainder(end-start, 2*math.Pi)
+		s.arc(v, start, sweep)
+		return
+	case JoinMiter:
+		// The miter length relative to the stroke width is 1/sin of half the angle between the segments
+		if sinHalf := math.Sqrt((1 + dot) / 2); sinHalf > 0 && 1/sinHalf <= s.miterLimit {
+			bisector := PointF{X: n0.X + n1.X, Y: n0.Y + n1.Y}
+			tip := offset(v, bisector, side*hw/(1+n0.X*n1.X+n0.Y*n1.Y))
+			s.polygon(v, outer0, tip, outer1)
+			return
+		}
+	}
+	s.polygon(v, outer0, outer1)
+}
+
+// capAt adds the cap at the end point of the segment from prev to end
+func (s *stroker) capAt(prev, end PointF) {
+	hw := s.halfWidth
+	n := unitNormal(prev, end)
+	switch s.lineCap {
+	case CapRound:
+		start := math.Atan2(n.Y, n.X)
+		// Sweep around the outside of the end point, away from prev
+		s.arc(end, start, -math.Pi)
+	case CapSquare:
+		d := PointF{X: n.Y, Y: -n.X}
+		s.polygon(offset(end, n, hw), offset(offset(end, n, hw), d, hw), offset(offset(end, n, -hw), d, hw), offset(end, n, -hw))
+	}
+}
@@ -0,0 +1,168 @@
+package pixman
+
+import (
+	"fmt"
+	"image"
+	"strings"
+	"unicode/utf8"
+)
+
+// TextAlign determines how lines of text are positioned horizontally within their block
+type TextAlign uint32
+
+const (
+	// AlignLeft starts each line at the left edge of the block
+	AlignLeft TextAlign = iota
+	// AlignCenter centres each line within the block
+	AlignCenter
+	// AlignRight ends each line at the right edge of the block
+	AlignRight
+)
+
+func (a TextAlign) String() string {
+	switch a {
+	case AlignLeft:
+		return "AlignLeft"
+	case AlignCenter:
+		return "AlignCenter"
+	case AlignRight:
+		return "AlignRight"
+	default:
+		return fmt.Sprintf("Unknown TextAlign: %d", uint32(a))
+	}
+}
+
+// TextOptions controls the layout of text by DrawString and MeasureString
+type TextOptions struct {
+	MaxWidth    int // Lines wider than this many pixels are wrapped between words, or 0 to only break at newlines
+	Align       TextAlign
+	LineSpacing int // Extra pixels between consecutive lines
+}
+
+// advance returns the width of s in pixels, the sum of its glyph advances
+func (f *Font) advance(s string) int {
+	width := 0
+	for _, r := range s {
+		if g, _ := f.glyph(r); g != nil {
+			width += g.advance
+		}
+	}
+	return width
+}
+
+// lines splits s at newlines, and wraps each line to fit within maxWidth if it is set
+func (f *Font) lines(s string, maxWidth int) []string {
+	var retval []string
+	for _, paragraph := range strings.Split(strings.ReplaceAll(s, "\r\n", "\n"), "\n") {
+		if maxWidth <= 0 {
+			retval = append(retval, paragraph)
+			continue
+		}
+		line := ""
+		for i, word := range strings.Split(paragraph, " ") {
+			candidate := word
+			if i > 0 {
+				candidate = line + " " + word
+			}
+			if i == 0 || f.advance(candidate) <= maxWidth {
+				line = candidate
+			} else {
+				retval = append(retval, line)
+				line = word
+			}
+			// A single word too wide for a line is broken between its characters
+			for f.advance(line) > maxWidth {
+				width, split := 0, 0
+				for j, r := range line {
+					g, _ := f.glyph(r)
+					if g != nil && j > 0 && width+g.advance > maxWidth {
+						break
+					}
+					if g != nil {
+						width += g.advance
+					}
+					// Invalid UTF-8 decodes as a 3 byte RuneError but only consumes 1 byte
+					_, size := utf8.DecodeRuneInString(line[j:])
+					split = j + size
+				}
+				if split >= len(line) {
+					break
+				}
+				retval = append(retval, line[:split])
+				line = line[split:]
+			}
+		}
+		retval = append(retval, line)
+	}
+	return retval
+}
+
+// blockWidth returns the width that lines are aligned within
+func (f *Font) blockWidth(lines []string, opts TextOptions) int {
+	if opts.MaxWidth > 0 {
+		return opts.MaxWidth
+	}
+	width := 0
+	for _, line := range lines {
+		width = max(width, f.advance(line))
+	}
+	return width
+}
+
+// MeasureString returns the size in pixels of the block of text DrawString would draw for s
+func (f *Font) MeasureString(s string, opts TextOptions) image.Point {
+	lines := f.lines(s, opts.MaxWidth)
+	return image.Pt(f.blockWidth(lines, opts), len(lines)*f.LineHeight()+(len(lines)-1)*opts.LineSpacing)
+}
+
+// DrawString composites src over dst through the glyphs of s, laid out in a block with its top left corner at (x, y).
+// Coordinates are in dst space, and src is aligned with dst.
+func (f *Font) DrawString(dst *Image, x, y int, s string, src *Image, opts TextOptions) error {
+	lines := f.lines(s, opts.MaxWidth)
+	width := f.blockWidth(lines, opts)
+
+	f.cache.Freeze()
+	defer f.cache.Thaw()
+	var glyphs []PixmanGlyph
+	for i, line := range lines {
+		penX := x
+		switch opts.Align {
+		case AlignCenter:
+			penX += (width - f.advance(line)) / 2
+		case AlignRight:
+			penX += width - f.advance(line)
+		}
+		baseline := y + f.ascent + i*(f.LineHeight()+opts.LineSpacing)
+		for _, r := range line {
+			g, key := f.glyph(r)
+			if g == nil {
+				continue
+			}
+			if g.width > 0 && g.height > 0 {
+				handle, err := f.cachedGlyph(g, key)
+				if err != nil {
+					return err
+				}
+				glyphs = append(glyphs, PixmanGlyph{X: int32(penX), Y: int32(baseline), Glyph: handle})
+			}
+			penX += g.advance
+		}
+	}
+	return CompositeGlyphs(PIXMAN_OP_OVER, src, dst, f.cache, glyphs)
+}
+
+// cachedGlyph returns the glyph cache handle for g, inserting it if it isn't cached yet. The cache must be frozen.
+func (f *Font) cachedGlyph(g *fontGlyph, key rune) (uintptr, error) {
+	if handle, ok := f.cache.Lookup(0, uintptr(key)); ok {
+		return handle, nil
+	}
+	img, err := imageCreate(PIXMAN_a8, g.width, g.height)
+	if err != nil {
+		return 0, err
+	}
+	stride := int(ImageGetStride(img.pixman))
+	for row := range g.height {
+		copy(img.rawData[row*stride:], g.bitmap[row*g.width:(row+1)*g.width])
+	}
+	return f.cache.Insert(0, uintptr(key), g.originX, g.originY, img)
+}
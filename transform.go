@@ -0,0 +1,57 @@
+package pixman
+
+import (
+	"fmt"
+	"math"
+)
+
+// Pixman transforms map destination coordinates to source coordinates, so
+// they describe the inverse of the visual effect. For example, to draw a
+// source image at twice its size, use TransformScale(0.5, 0.5).
+
+// TransformIdentity returns a transform that leaves coordinates unchanged
+func TransformIdentity() Transform {
+	var t Transform
+	TransformInitIdentity(&t)
+	return t
+}
+
+// TransformScale returns a transform that scales coordinates by sx and sy
+func TransformScale(sx, sy float64) Transform {
+	var t Transform
+	TransformInitScale(&t, FixedFromFloat(sx), FixedFromFloat(sy))
+	return t
+}
+
+// TransformRotate returns a transform that rotates coordinates by angle radians around the origin
+func TransformRotate(angle float64) Transform {
+	var t Transform
+	TransformInitRotate(&t, FixedFromFloat(math.Cos(angle)), FixedFromFloat(math.Sin(angle)))
+	return t
+}
+
+// TransformTranslate returns a transform that offsets coordinates by tx and ty
+func TransformTranslate(tx, ty float64) Transform {
+	var t Transform
+	TransformInitTranslate(&t, FixedFromFloat(tx), FixedFromFloat(ty))
+	return t
+}
+
+// Multiply returns the product t x other. When applied to a point, other is
+// applied first, followed by t.
+func (t Transform) Multiply(other Transform) (Transform, error) {
+	var result Transform
+	if !TransformMultiply(&result, &t, &other) {
+		return Transform{}, fmt.Errorf("transform multiplication overflowed")
+	}
+	return result, nil
+}
+
+// Invert returns the inverse of t
+func (t Transform) Invert() (Transform, error) {
+	var result Transform
+	if !TransformInvert(&result, &t) {
+		return Transform{}, fmt.Errorf("transform %v is not invertible", t.Matrix)
+	}
+	return result, nil
+}
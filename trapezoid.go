@@ -0,0 +1,43 @@
+package pixman
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+)
+
+// AddTrapezoids adds the anti-aliased coverage of traps, offset by (xOff, yOff), to this image.
+// The image must have an alpha-only format such as PIXMAN_a8. Pixman takes xOff as an int16.
+func (i *Image) AddTrapezoids(xOff, yOff int, traps []PixmanTrapezoid) error {
+	if xOff < math.MinInt16 || xOff > math.MaxInt16 || yOff < math.MinInt32 || yOff > math.MaxInt32 {
+		return fmt.Errorf("trapezoid offset (%d,%d) is out of range", xOff, yOff)
+	}
+	if len(traps) == 0 {
+		return nil
+	}
+	AddTrapezoids(i.pixman, int16(xOff), int32(yOff), int32(len(traps)), &traps[0])
+	runtime.KeepAlive(i)
+	return nil
+}
+
+// RasterizeTrapezoid adds the anti-aliased coverage of trap, offset by (xOff, yOff), to this image.
+// The image must have an alpha-only format such as PIXMAN_a8. Pixman takes the offsets as C ints.
+func (i *Image) RasterizeTrapezoid(trap PixmanTrapezoid, xOff, yOff int) error {
+	if xOff < math.MinInt32 || xOff > math.MaxInt32 || yOff < math.MinInt32 || yOff > math.MaxInt32 {
+		return fmt.Errorf("trapezoid offset (%d,%d) is out of range", xOff, yOff)
+	}
+	RasterizeTrapezoid(i.pixman, &trap, int32(xOff), int32(yOff))
+	runtime.KeepAlive(i)
+	return nil
+}
+
+// CompositeTrapezoids composites src onto dst using op, through a mask of format maskFormat holding the
+// coverage of traps. Trapezoid coordinates are in dst space, and src is aligned with dst.
+func CompositeTrapezoids(op PixmanOperation, src, dst *Image, maskFormat PixmanFormatCode, traps []PixmanTrapezoid) {
+	if len(traps) == 0 {
+		return
+	}
+	pixmanCompositeTrapezoids(op, src.pixman, dst.pixman, maskFormat, 0, 0, 0, 0, int32(len(traps)), &traps[0])
+	runtime.KeepAlive(src)
+	runtime.KeepAlive(dst)
+}
@@ -0,0 +1,33 @@
+package pixman
+
+import (
+	"fmt"
+	"math"
+	"runtime"
+)
+
+// AddTriangles adds the anti-aliased coverage of tris, offset by (xOff, yOff), to this image.
+// The image must have an alpha-only format such as PIXMAN_a8. Pixman takes the offsets as C ints.
+func (i *Image) AddTriangles(xOff, yOff int, tris []PixmanTriangle) error {
+	if xOff < math.MinInt32 || xOff > math.MaxInt32 || yOff < math.MinInt32 || yOff > math.MaxInt32 {
+		return fmt.Errorf("triangle offset (%d,%d) is out of range", xOff, yOff)
+	}
+	if len(tris) == 0 {
+		return nil
+	}
+	AddTriangles(i.pixman, int32(xOff), int32(yOff), int32(len(tris)), &tris[0])
+	runtime.KeepAlive(i)
+	return nil
+}
+
+// CompositeTriangles composites src onto dst using op, through a mask of format maskFormat holding the
+// coverage of tris. Triangle coordinates are in dst space, and src is aligned with dst. Coverage of
+// overlapping triangles is summed, so a triangle fan that shares edges renders without seams.
+func CompositeTriangles(op PixmanOperation, src, dst *Image, maskFormat PixmanFormatCode, tris []PixmanTriangle) {
+	if len(tris) == 0 {
+		return
+	}
+	pixmanCompositeTriangles(op, src.pixman, dst.pixman, maskFormat, 0, 0, 0, 0, int32(len(tris)), &tris[0])
+	runtime.KeepAlive(src)
+	runtime.KeepAlive(dst)
+}
@@ -3,19 +3,55 @@ package pixman
 import "fmt"
 
 type PixmanFormatCode uint32
+type PixmanFormatType uint32
 type PixmanOperation uint32
+type PixmanFilter uint32
+type PixmanKernel uint32
+type PixmanRepeat uint32
+type PixmanRegionOverlap uint32
 
-// Pixman format codes (partial list, add more as needed)
+// PixmanFixed mirrors the C type pixman_fixed_t, a signed 16.16 fixed-point number
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanFixed int32
+
+// Pixman format codes
 // See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h#L1044
 // Note: The lack of macros in Go means we have to manually define these
 // See helper/helper.c to regenerate
 const (
-	PIXMAN_a8r8g8b8 PixmanFormatCode = 0x20028888
-	PIXMAN_x8r8g8b8 PixmanFormatCode = 0x20020888
-	PIXMAN_a8b8g8r8 PixmanFormatCode = 0x20038888
-	PIXMAN_x8b8g8r8 PixmanFormatCode = 0x20030888
-	PIXMAN_b8g8r8a8 PixmanFormatCode = 0x20088888
-	PIXMAN_b8g8r8x8 PixmanFormatCode = 0x20080888
+	// 128bpp formats
+	PIXMAN_rgba_float PixmanFormatCode = 0x10cb4444
+
+	// 96bpp formats
+	PIXMAN_rgb_float PixmanFormatCode = 0x0ccb0444
+
+	// 64bpp formats
+	PIXMAN_a16b16g16r16 PixmanFormatCode = 0x08c32222
+
+	// 32bpp formats
+	PIXMAN_a8r8g8b8    PixmanFormatCode = 0x20028888
+	PIXMAN_x8r8g8b8    PixmanFormatCode = 0x20020888
+	PIXMAN_a8b8g8r8    PixmanFormatCode = 0x20038888
+	PIXMAN_x8b8g8r8    PixmanFormatCode = 0x20030888
+	PIXMAN_b8g8r8a8    PixmanFormatCode = 0x20088888
+	PIXMAN_b8g8r8x8    PixmanFormatCode = 0x20080888
+	PIXMAN_r8g8b8a8    PixmanFormatCode = 0x20098888
+	PIXMAN_r8g8b8x8    PixmanFormatCode = 0x20090888
+	PIXMAN_x14r6g6b6   PixmanFormatCode = 0x20020666
+	PIXMAN_x2r10g10b10 PixmanFormatCode = 0x20020aaa
+	PIXMAN_a2r10g10b10 PixmanFormatCode = 0x20022aaa
+	PIXMAN_x2b10g10r10 PixmanFormatCode = 0x20030aaa
+	PIXMAN_a2b10g10r10 PixmanFormatCode = 0x20032aaa
+
+	// sRGB formats
+	PIXMAN_a8r8g8b8_sRGB PixmanFormatCode = 0x200a8888
+	PIXMAN_r8g8b8_sRGB   PixmanFormatCode = 0x180a0888
+
+	// 24bpp formats
+	PIXMAN_r8g8b8 PixmanFormatCode = 0x18020888
+	PIXMAN_b8g8r8 PixmanFormatCode = 0x18030888
+
+	// 16bpp formats
 	PIXMAN_r5g6b5   PixmanFormatCode = 0x10020565
 	PIXMAN_b5g6r5   PixmanFormatCode = 0x10030565
 	PIXMAN_a1r5g5b5 PixmanFormatCode = 0x10021555
@@ -26,8 +62,52 @@ const (
 	PIXMAN_x4r4g4b4 PixmanFormatCode = 0x10020444
 	PIXMAN_a4b4g4r4 PixmanFormatCode = 0x10034444
 	PIXMAN_x4b4g4r4 PixmanFormatCode = 0x10030444
-	PIXMAN_r8g8b8a8 PixmanFormatCode = 0x20098888
-	PIXMAN_r8g8b8x8 PixmanFormatCode = 0x20090888
+
+	// 8bpp formats
+	PIXMAN_a8       PixmanFormatCode = 0x08018000
+	PIXMAN_r3g3b2   PixmanFormatCode = 0x08020332
+	PIXMAN_b2g3r3   PixmanFormatCode = 0x08030332
+	PIXMAN_a2r2g2b2 PixmanFormatCode = 0x08022222
+	PIXMAN_a2b2g2r2 PixmanFormatCode = 0x08032222
+	PIXMAN_c8       PixmanFormatCode = 0x08040000
+	PIXMAN_g8       PixmanFormatCode = 0x08050000
+	PIXMAN_x4a4     PixmanFormatCode = 0x08014000
+	PIXMAN_x4c4     PixmanFormatCode = 0x08040000
+	PIXMAN_x4g4     PixmanFormatCode = 0x08050000
+
+	// 4bpp formats
+	PIXMAN_a4       PixmanFormatCode = 0x04014000
+	PIXMAN_r1g2b1   PixmanFormatCode = 0x04020121
+	PIXMAN_b1g2r1   PixmanFormatCode = 0x04030121
+	PIXMAN_a1r1g1b1 PixmanFormatCode = 0x04021111
+	PIXMAN_a1b1g1r1 PixmanFormatCode = 0x04031111
+	PIXMAN_c4       PixmanFormatCode = 0x04040000
+	PIXMAN_g4       PixmanFormatCode = 0x04050000
+
+	// 1bpp formats
+	PIXMAN_a1 PixmanFormatCode = 0x01011000
+	PIXMAN_g1 PixmanFormatCode = 0x01050000
+
+	// YUV formats
+	PIXMAN_yuy2 PixmanFormatCode = 0x10060000
+	PIXMAN_yv12 PixmanFormatCode = 0x0c070000
+)
+
+// Pixman format types, describing how the channels of a format are laid out
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+const (
+	PIXMAN_TYPE_OTHER      PixmanFormatType = 0
+	PIXMAN_TYPE_A          PixmanFormatType = 1
+	PIXMAN_TYPE_ARGB       PixmanFormatType = 2
+	PIXMAN_TYPE_ABGR       PixmanFormatType = 3
+	PIXMAN_TYPE_COLOR      PixmanFormatType = 4
+	PIXMAN_TYPE_GRAY       PixmanFormatType = 5
+	PIXMAN_TYPE_YUY2       PixmanFormatType = 6
+	PIXMAN_TYPE_YV12       PixmanFormatType = 7
+	PIXMAN_TYPE_BGRA       PixmanFormatType = 8
+	PIXMAN_TYPE_RGBA       PixmanFormatType = 9
+	PIXMAN_TYPE_ARGB_SRGB  PixmanFormatType = 10
+	PIXMAN_TYPE_RGBA_FLOAT PixmanFormatType = 11
 )
 
 // Pixman composite operations
@@ -47,6 +127,94 @@ const (
 	PIXMAN_OP_XOR          PixmanOperation = 0x0b
 	PIXMAN_OP_ADD          PixmanOperation = 0x0c
 	PIXMAN_OP_SATURATE     PixmanOperation = 0x0d
+
+	// Disjoint operators treat the source and destination coverage as not overlapping where possible
+	PIXMAN_OP_DISJOINT_CLEAR        PixmanOperation = 0x10
+	PIXMAN_OP_DISJOINT_SRC          PixmanOperation = 0x11
+	PIXMAN_OP_DISJOINT_DST          PixmanOperation = 0x12
+	PIXMAN_OP_DISJOINT_OVER         PixmanOperation = 0x13
+	PIXMAN_OP_DISJOINT_OVER_REVERSE PixmanOperation = 0x14
+	PIXMAN_OP_DISJOINT_IN           PixmanOperation = 0x15
+	PIXMAN_OP_DISJOINT_IN_REVERSE   PixmanOperation = 0x16
+	PIXMAN_OP_DISJOINT_OUT          PixmanOperation = 0x17
+	PIXMAN_OP_DISJOINT_OUT_REVERSE  PixmanOperation = 0x18
+	PIXMAN_OP_DISJOINT_ATOP         PixmanOperation = 0x19
+	PIXMAN_OP_DISJOINT_ATOP_REVERSE PixmanOperation = 0x1a
+	PIXMAN_OP_DISJOINT_XOR          PixmanOperation = 0x1b
+
+	// Conjoint operators treat the source and destination coverage as overlapping as much as possible
+	PIXMAN_OP_CONJOINT_CLEAR        PixmanOperation = 0x20
+	PIXMAN_OP_CONJOINT_SRC          PixmanOperation = 0x21
+	PIXMAN_OP_CONJOINT_DST          PixmanOperation = 0x22
+	PIXMAN_OP_CONJOINT_OVER         PixmanOperation = 0x23
+	PIXMAN_OP_CONJOINT_OVER_REVERSE PixmanOperation = 0x24
+	PIXMAN_OP_CONJOINT_IN           PixmanOperation = 0x25
+	PIXMAN_OP_CONJOINT_IN_REVERSE   PixmanOperation = 0x26
+	PIXMAN_OP_CONJOINT_OUT          PixmanOperation = 0x27
+	PIXMAN_OP_CONJOINT_OUT_REVERSE  PixmanOperation = 0x28
+	PIXMAN_OP_CONJOINT_ATOP         PixmanOperation = 0x29
+	PIXMAN_OP_CONJOINT_ATOP_REVERSE PixmanOperation = 0x2a
+	PIXMAN_OP_CONJOINT_XOR          PixmanOperation = 0x2b
+
+	// PDF blend modes, the separable ones first and then the non-separable HSL modes
+	PIXMAN_OP_MULTIPLY       PixmanOperation = 0x30
+	PIXMAN_OP_SCREEN         PixmanOperation = 0x31
+	PIXMAN_OP_OVERLAY        PixmanOperation = 0x32
+	PIXMAN_OP_DARKEN         PixmanOperation = 0x33
+	PIXMAN_OP_LIGHTEN        PixmanOperation = 0x34
+	PIXMAN_OP_COLOR_DODGE    PixmanOperation = 0x35
+	PIXMAN_OP_COLOR_BURN     PixmanOperation = 0x36
+	PIXMAN_OP_HARD_LIGHT     PixmanOperation = 0x37
+	PIXMAN_OP_SOFT_LIGHT     PixmanOperation = 0x38
+	PIXMAN_OP_DIFFERENCE     PixmanOperation = 0x39
+	PIXMAN_OP_EXCLUSION      PixmanOperation = 0x3a
+	PIXMAN_OP_HSL_HUE        PixmanOperation = 0x3b
+	PIXMAN_OP_HSL_SATURATION PixmanOperation = 0x3c
+	PIXMAN_OP_HSL_COLOR      PixmanOperation = 0x3d
+	PIXMAN_OP_HSL_LUMINOSITY PixmanOperation = 0x3e
+)
+
+// Pixman sampling filters, used when a source image is transformed
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+const (
+	PIXMAN_FILTER_FAST     PixmanFilter = 0
+	PIXMAN_FILTER_GOOD     PixmanFilter = 1
+	PIXMAN_FILTER_BEST     PixmanFilter = 2
+	PIXMAN_FILTER_NEAREST  PixmanFilter = 3
+	PIXMAN_FILTER_BILINEAR PixmanFilter = 4
+
+	PIXMAN_FILTER_CONVOLUTION           PixmanFilter = 5
+	PIXMAN_FILTER_SEPARABLE_CONVOLUTION PixmanFilter = 6
+)
+
+// Pixman kernels, used to build separable convolution filters
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+const (
+	PIXMAN_KERNEL_IMPULSE            PixmanKernel = 0
+	PIXMAN_KERNEL_BOX                PixmanKernel = 1
+	PIXMAN_KERNEL_LINEAR             PixmanKernel = 2
+	PIXMAN_KERNEL_CUBIC              PixmanKernel = 3
+	PIXMAN_KERNEL_GAUSSIAN           PixmanKernel = 4
+	PIXMAN_KERNEL_LANCZOS2           PixmanKernel = 5
+	PIXMAN_KERNEL_LANCZOS3           PixmanKernel = 6
+	PIXMAN_KERNEL_LANCZOS3_STRETCHED PixmanKernel = 7
+)
+
+// Pixman repeat modes, controlling how a source image is sampled outside its bounds
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+const (
+	PIXMAN_REPEAT_NONE    PixmanRepeat = 0
+	PIXMAN_REPEAT_NORMAL  PixmanRepeat = 1
+	PIXMAN_REPEAT_PAD     PixmanRepeat = 2
+	PIXMAN_REPEAT_REFLECT PixmanRepeat = 3
+)
+
+// Results of testing whether a rectangle lies within a region
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+const (
+	PIXMAN_REGION_OUT  PixmanRegionOverlap = 0
+	PIXMAN_REGION_IN   PixmanRegionOverlap = 1
+	PIXMAN_REGION_PART PixmanRegionOverlap = 2
 )
 
 // PixmanColor mirrors the C struct pixman_color_t
@@ -58,15 +226,177 @@ type PixmanColor struct {
 	Alpha uint16
 }
 
-// Determines the depth in bits-per-pixel for a given Pixman format code.
+// PixmanPointFixed mirrors the C struct pixman_point_fixed_t
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanPointFixed struct {
+	X PixmanFixed
+	Y PixmanFixed
+}
+
+// PixmanGradientStop mirrors the C struct pixman_gradient_stop_t
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanGradientStop struct {
+	X     PixmanFixed
+	Color PixmanColor
+}
+
+// PixmanBox32 mirrors the C struct pixman_box32_t. The box includes (X1, Y1) but excludes (X2, Y2).
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanBox32 struct {
+	X1 int32
+	Y1 int32
+	X2 int32
+	Y2 int32
+}
+
+// PixmanRegion32 mirrors the C struct pixman_region32_t
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanRegion32 struct {
+	Extents PixmanBox32
+	Data    uintptr // pixman_region32_data_t *, owned by pixman
+}
+
+// PixmanLineFixed mirrors the C struct pixman_line_fixed_t, the infinite line through P1 and P2
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanLineFixed struct {
+	P1 PixmanPointFixed
+	P2 PixmanPointFixed
+}
+
+// PixmanTrapezoid mirrors the C struct pixman_trapezoid_t, the area between Top and Bottom bounded by the Left and Right lines
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanTrapezoid struct {
+	Top    PixmanFixed
+	Bottom PixmanFixed
+	Left   PixmanLineFixed
+	Right  PixmanLineFixed
+}
+
+// PixmanTriangle mirrors the C struct pixman_triangle_t
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanTriangle struct {
+	P1 PixmanPointFixed
+	P2 PixmanPointFixed
+	P3 PixmanPointFixed
+}
+
+// PixmanGlyph mirrors the C struct pixman_glyph_t, placing a cached glyph with its origin at (X, Y)
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type PixmanGlyph struct {
+	X     int32
+	Y     int32
+	Glyph uintptr // As returned by GlyphCache.Insert or GlyphCache.Lookup
+}
+
+// Transform mirrors the C struct pixman_transform_t, a 3x3 matrix of fixed-point values.
+// See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h
+type Transform struct {
+	Matrix [3][3]PixmanFixed
+}
+
+// FixedFromInt converts an integer to a PixmanFixed value
+func FixedFromInt(i int) PixmanFixed {
+	return PixmanFixed(i << 16)
+}
+
+// FixedFromFloat converts a floating point value to a PixmanFixed value.
+// Like pixman_double_to_fixed, the fractional part is truncated.
+func FixedFromFloat(f float64) PixmanFixed {
+	return PixmanFixed(f * 65536.0)
+}
+
+// PointFixedFromFloat converts floating point coordinates to a PixmanPointFixed
+func PointFixedFromFloat(x, y float64) PixmanPointFixed {
+	return PixmanPointFixed{X: FixedFromFloat(x), Y: FixedFromFloat(y)}
+}
+
+// LineFixedFromFloat converts the floating point line through (x1, y1) and (x2, y2) to a PixmanLineFixed
+func LineFixedFromFloat(x1, y1, x2, y2 float64) PixmanLineFixed {
+	return PixmanLineFixed{P1: PointFixedFromFloat(x1, y1), P2: PointFixedFromFloat(x2, y2)}
+}
+
+// TriangleFromFloat converts the floating point triangle (x1, y1), (x2, y2), (x3, y3) to a PixmanTriangle
+func TriangleFromFloat(x1, y1, x2, y2, x3, y3 float64) PixmanTriangle {
+	return PixmanTriangle{P1: PointFixedFromFloat(x1, y1), P2: PointFixedFromFloat(x2, y2), P3: PointFixedFromFloat(x3, y3)}
+}
+
+// Int returns the integer part of f, rounded towards negative infinity
+func (f PixmanFixed) Int() int {
+	return int(f >> 16)
+}
+
+// Float returns f as a floating point value
+func (f PixmanFixed) Float() float64 {
+	return float64(f) / 65536.0
+}
+
+// reshift extracts the num bit wide field at ofs from the format code. Formats with 8-bit aligned channels, such as
+// the float formats, store their sizes divided by a power of two, recorded in bits 22 and 23.
 // See https://gitlab.freedesktop.org/pixman/pixman/-/blob/9879f6cfc40b4ef3bdca4ee9aaedacff8fb87244/pixman/pixman.h#L1010
+func (f PixmanFormatCode) reshift(ofs, num uint) int {
+	return int(((uint32(f) >> ofs) & (1<<num - 1)) << ((uint32(f) >> 22) & 3))
+}
+
+// Determines the depth in bits-per-pixel for a given Pixman format code.
 func (f PixmanFormatCode) BPP() int {
-	return int((uint32(f) >> 24) & 0xFF)
+	return f.reshift(24, 8)
+}
+
+// Type returns how the channels of the format are laid out
+func (f PixmanFormatCode) Type() PixmanFormatType {
+	return PixmanFormatType((uint32(f) >> 16) & 0x3f)
+}
+
+// A returns the number of bits of alpha in the format
+func (f PixmanFormatCode) A() int {
+	return f.reshift(12, 4)
+}
+
+// R returns the number of bits of red in the format
+func (f PixmanFormatCode) R() int {
+	return f.reshift(8, 4)
+}
 
+// G returns the number of bits of green in the format
+func (f PixmanFormatCode) G() int {
+	return f.reshift(4, 4)
+}
+
+// B returns the number of bits of blue in the format
+func (f PixmanFormatCode) B() int {
+	return f.reshift(0, 4)
+}
+
+// Depth returns the number of significant bits in a pixel, excluding padding
+func (f PixmanFormatCode) Depth() int {
+	return f.A() + f.R() + f.G() + f.B()
+}
+
+// HasAlpha reports whether the format stores an alpha channel
+func (f PixmanFormatCode) HasAlpha() bool {
+	return f.A() > 0
+}
+
+// IsColor reports whether the format stores red, green and blue channels directly,
+// rather than indexing a palette, storing grey levels or only alpha
+func (f PixmanFormatCode) IsColor() bool {
+	switch f.Type() {
+	case PIXMAN_TYPE_ARGB, PIXMAN_TYPE_ABGR, PIXMAN_TYPE_BGRA, PIXMAN_TYPE_RGBA, PIXMAN_TYPE_RGBA_FLOAT:
+		return true
+	default:
+		return false
+	}
 }
 
 func (f PixmanFormatCode) String() string {
+	// x4c4 and x4g4 share their codes with c8 and g8, so they are reported under those names
 	switch f {
+	case PIXMAN_rgba_float:
+		return "PIXMAN_rgba_float"
+	case PIXMAN_rgb_float:
+		return "PIXMAN_rgb_float"
+	case PIXMAN_a16b16g16r16:
+		return "PIXMAN_a16b16g16r16"
 	case PIXMAN_a8r8g8b8:
 		return "PIXMAN_a8r8g8b8"
 	case PIXMAN_x8r8g8b8:
@@ -79,15 +409,303 @@ func (f PixmanFormatCode) String() string {
 		return "PIXMAN_b8g8r8a8"
 	case PIXMAN_b8g8r8x8:
 		return "PIXMAN_b8g8r8x8"
-	case PIXMAN_r5g6b5:
-		return "PIXMAN_r5g6b5"
-	case PIXMAN_b5g6r5:
-		return "PIXMAN_b5g6r5"
 	case PIXMAN_r8g8b8a8:
 		return "PIXMAN_r8g8b8a8"
 	case PIXMAN_r8g8b8x8:
 		return "PIXMAN_r8g8b8x8"
+	case PIXMAN_x14r6g6b6:
+		return "PIXMAN_x14r6g6b6"
+	case PIXMAN_x2r10g10b10:
+		return "PIXMAN_x2r10g10b10"
+	case PIXMAN_a2r10g10b10:
+		return "PIXMAN_a2r10g10b10"
+	case PIXMAN_x2b10g10r10:
+		return "PIXMAN_x2b10g10r10"
+	case PIXMAN_a2b10g10r10:
+		return "PIXMAN_a2b10g10r10"
+	case PIXMAN_a8r8g8b8_sRGB:
+		return "PIXMAN_a8r8g8b8_sRGB"
+	case PIXMAN_r8g8b8_sRGB:
+		return "PIXMAN_r8g8b8_sRGB"
+	case PIXMAN_r8g8b8:
+		return "PIXMAN_r8g8b8"
+	case PIXMAN_b8g8r8:
+		return "PIXMAN_b8g8r8"
+	case PIXMAN_r5g6b5:
+		return "PIXMAN_r5g6b5"
+	case PIXMAN_b5g6r5:
+		return "PIXMAN_b5g6r5"
+	case PIXMAN_a1r5g5b5:
+		return "PIXMAN_a1r5g5b5"
+	case PIXMAN_x1r5g5b5:
+		return "PIXMAN_x1r5g5b5"
+	case PIXMAN_a1b5g5r5:
+		return "PIXMAN_a1b5g5r5"
+	case PIXMAN_x1b5g5r5:
+		return "PIXMAN_x1b5g5r5"
+	case PIXMAN_a4r4g4b4:
+		return "PIXMAN_a4r4g4b4"
+	case PIXMAN_x4r4g4b4:
+		return "PIXMAN_x4r4g4b4"
+	case PIXMAN_a4b4g4r4:
+		return "PIXMAN_a4b4g4r4"
+	case PIXMAN_x4b4g4r4:
+		return "PIXMAN_x4b4g4r4"
+	case PIXMAN_a8:
+		return "PIXMAN_a8"
+	case PIXMAN_r3g3b2:
+		return "PIXMAN_r3g3b2"
+	case PIXMAN_b2g3r3:
+		return "PIXMAN_b2g3r3"
+	case PIXMAN_a2r2g2b2:
+		return "PIXMAN_a2r2g2b2"
+	case PIXMAN_a2b2g2r2:
+		return "PIXMAN_a2b2g2r2"
+	case PIXMAN_c8:
+		return "PIXMAN_c8"
+	case PIXMAN_g8:
+		return "PIXMAN_g8"
+	case PIXMAN_x4a4:
+		return "PIXMAN_x4a4"
+	case PIXMAN_a4:
+		return "PIXMAN_a4"
+	case PIXMAN_r1g2b1:
+		return "PIXMAN_r1g2b1"
+	case PIXMAN_b1g2r1:
+		return "PIXMAN_b1g2r1"
+	case PIXMAN_a1r1g1b1:
+		return "PIXMAN_a1r1g1b1"
+	case PIXMAN_a1b1g1r1:
+		return "PIXMAN_a1b1g1r1"
+	case PIXMAN_c4:
+		return "PIXMAN_c4"
+	case PIXMAN_g4:
+		return "PIXMAN_g4"
+	case PIXMAN_a1:
+		return "PIXMAN_a1"
+	case PIXMAN_g1:
+		return "PIXMAN_g1"
+	case PIXMAN_yuy2:
+		return "PIXMAN_yuy2"
+	case PIXMAN_yv12:
+		return "PIXMAN_yv12"
 	default:
 		return fmt.Sprintf("Unknown PixmanFormatCode: %x", uint32(f))
 	}
 }
+
+func (t PixmanFormatType) String() string {
+	switch t {
+	case PIXMAN_TYPE_OTHER:
+		return "PIXMAN_TYPE_OTHER"
+	case PIXMAN_TYPE_A:
+		return "PIXMAN_TYPE_A"
+	case PIXMAN_TYPE_ARGB:
+		return "PIXMAN_TYPE_ARGB"
+	case PIXMAN_TYPE_ABGR:
+		return "PIXMAN_TYPE_ABGR"
+	case PIXMAN_TYPE_COLOR:
+		return "PIXMAN_TYPE_COLOR"
+	case PIXMAN_TYPE_GRAY:
+		return "PIXMAN_TYPE_GRAY"
+	case PIXMAN_TYPE_YUY2:
+		return "PIXMAN_TYPE_YUY2"
+	case PIXMAN_TYPE_YV12:
+		return "PIXMAN_TYPE_YV12"
+	case PIXMAN_TYPE_BGRA:
+		return "PIXMAN_TYPE_BGRA"
+	case PIXMAN_TYPE_RGBA:
+		return "PIXMAN_TYPE_RGBA"
+	case PIXMAN_TYPE_ARGB_SRGB:
+		return "PIXMAN_TYPE_ARGB_SRGB"
+	case PIXMAN_TYPE_RGBA_FLOAT:
+		return "PIXMAN_TYPE_RGBA_FLOAT"
+	default:
+		return fmt.Sprintf("Unknown PixmanFormatType: %d", uint32(t))
+	}
+}
+
+func (o PixmanOperation) String() string {
+	switch o {
+	case PIXMAN_OP_CLEAR:
+		return "PIXMAN_OP_CLEAR"
+	case PIXMAN_OP_SRC:
+		return "PIXMAN_OP_SRC"
+	case PIXMAN_OP_DST:
+		return "PIXMAN_OP_DST"
+	case PIXMAN_OP_OVER:
+		return "PIXMAN_OP_OVER"
+	case PIXMAN_OP_OVER_REVERSE:
+		return "PIXMAN_OP_OVER_REVERSE"
+	case PIXMAN_OP_IN:
+		return "PIXMAN_OP_IN"
+	case PIXMAN_OP_IN_REVERSE:
+		return "PIXMAN_OP_IN_REVERSE"
+	case PIXMAN_OP_OUT:
+		return "PIXMAN_OP_OUT"
+	case PIXMAN_OP_OUT_REVERSE:
+		return "PIXMAN_OP_OUT_REVERSE"
+	case PIXMAN_OP_ATOP:
+		return "PIXMAN_OP_ATOP"
+	case PIXMAN_OP_ATOP_REVERSE:
+		return "PIXMAN_OP_ATOP_REVERSE"
+	case PIXMAN_OP_XOR:
+		return "PIXMAN_OP_XOR"
+	case PIXMAN_OP_ADD:
+		return "PIXMAN_OP_ADD"
+	case PIXMAN_OP_SATURATE:
+		return "PIXMAN_OP_SATURATE"
+	case PIXMAN_OP_DISJOINT_CLEAR:
+		return "PIXMAN_OP_DISJOINT_CLEAR"
+	case PIXMAN_OP_DISJOINT_SRC:
+		return "PIXMAN_OP_DISJOINT_SRC"
+	case PIXMAN_OP_DISJOINT_DST:
+		return "PIXMAN_OP_DISJOINT_DST"
+	case PIXMAN_OP_DISJOINT_OVER:
+		return "PIXMAN_OP_DISJOINT_OVER"
+	case PIXMAN_OP_DISJOINT_OVER_REVERSE:
+		return "PIXMAN_OP_DISJOINT_OVER_REVERSE"
+	case PIXMAN_OP_DISJOINT_IN:
+		return "PIXMAN_OP_DISJOINT_IN"
+	case PIXMAN_OP_DISJOINT_IN_REVERSE:
+		return "PIXMAN_OP_DISJOINT_IN_REVERSE"
+	case PIXMAN_OP_DISJOINT_OUT:
+		return "PIXMAN_OP_DISJOINT_OUT"
+	case PIXMAN_OP_DISJOINT_OUT_REVERSE:
+		return "PIXMAN_OP_DISJOINT_OUT_REVERSE"
+	case PIXMAN_OP_DISJOINT_ATOP:
+		return "PIXMAN_OP_DISJOINT_ATOP"
+	case PIXMAN_OP_DISJOINT_ATOP_REVERSE:
+		return "PIXMAN_OP_DISJOINT_ATOP_REVERSE"
+	case PIXMAN_OP_DISJOINT_XOR:
+		return "PIXMAN_OP_DISJOINT_XOR"
+	case PIXMAN_OP_CONJOINT_CLEAR:
+		return "PIXMAN_OP_CONJOINT_CLEAR"
+	case PIXMAN_OP_CONJOINT_SRC:
+		return "PIXMAN_OP_CONJOINT_SRC"
+	case PIXMAN_OP_CONJOINT_DST:
+		return "PIXMAN_OP_CONJOINT_DST"
+	case PIXMAN_OP_CONJOINT_OVER:
+		return "PIXMAN_OP_CONJOINT_OVER"
+	case PIXMAN_OP_CONJOINT_OVER_REVERSE:
+		return "PIXMAN_OP_CONJOINT_OVER_REVERSE"
+	case PIXMAN_OP_CONJOINT_IN:
+		return "PIXMAN_OP_CONJOINT_IN"
+	case PIXMAN_OP_CONJOINT_IN_REVERSE:
+		return "PIXMAN_OP_CONJOINT_IN_REVERSE"
+	case PIXMAN_OP_CONJOINT_OUT:
+		return "PIXMAN_OP_CONJOINT_OUT"
+	case PIXMAN_OP_CONJOINT_OUT_REVERSE:
+		return "PIXMAN_OP_CONJOINT_OUT_REVERSE"
+	case PIXMAN_OP_CONJOINT_ATOP:
+		return "PIXMAN_OP_CONJOINT_ATOP"
+	case PIXMAN_OP_CONJOINT_ATOP_REVERSE:
+		return "PIXMAN_OP_CONJOINT_ATOP_REVERSE"
+	case PIXMAN_OP_CONJOINT_XOR:
+		return "PIXMAN_OP_CONJOINT_XOR"
+	case PIXMAN_OP_MULTIPLY:
+		return "PIXMAN_OP_MULTIPLY"
+	case PIXMAN_OP_SCREEN:
+		return "PIXMAN_OP_SCREEN"
+	case PIXMAN_OP_OVERLAY:
+		return "PIXMAN_OP_OVERLAY"
+	case PIXMAN_OP_DARKEN:
+		return "PIXMAN_OP_DARKEN"
+	case PIXMAN_OP_LIGHTEN:
+		return "PIXMAN_OP_LIGHTEN"
+	case PIXMAN_OP_COLOR_DODGE:
+		return "PIXMAN_OP_COLOR_DODGE"
+	case PIXMAN_OP_COLOR_BURN:
+		return "PIXMAN_OP_COLOR_BURN"
+	case PIXMAN_OP_HARD_LIGHT:
+		return "PIXMAN_OP_HARD_LIGHT"
+	case PIXMAN_OP_SOFT_LIGHT:
+		return "PIXMAN_OP_SOFT_LIGHT"
+	case PIXMAN_OP_DIFFERENCE:
+		return "PIXMAN_OP_DIFFERENCE"
+	case PIXMAN_OP_EXCLUSION:
+		return "PIXMAN_OP_EXCLUSION"
+	case PIXMAN_OP_HSL_HUE:
+		return "PIXMAN_OP_HSL_HUE"
+	case PIXMAN_OP_HSL_SATURATION:
+		return "PIXMAN_OP_HSL_SATURATION"
+	case PIXMAN_OP_HSL_COLOR:
+		return "PIXMAN_OP_HSL_COLOR"
+	case PIXMAN_OP_HSL_LUMINOSITY:
+		return "PIXMAN_OP_HSL_LUMINOSITY"
+	default:
+		return fmt.Sprintf("Unknown PixmanOperation: %d", uint32(o))
+	}
+}
+
+func (f PixmanFilter) String() string {
+	switch f {
+	case PIXMAN_FILTER_FAST:
+		return "PIXMAN_FILTER_FAST"
+	case PIXMAN_FILTER_GOOD:
+		return "PIXMAN_FILTER_GOOD"
+	case PIXMAN_FILTER_BEST:
+		return "PIXMAN_FILTER_BEST"
+	case PIXMAN_FILTER_NEAREST:
+		return "PIXMAN_FILTER_NEAREST"
+	case PIXMAN_FILTER_BILINEAR:
+		return "PIXMAN_FILTER_BILINEAR"
+	case PIXMAN_FILTER_CONVOLUTION:
+		return "PIXMAN_FILTER_CONVOLUTION"
+	case PIXMAN_FILTER_SEPARABLE_CONVOLUTION:
+		return "PIXMAN_FILTER_SEPARABLE_CONVOLUTION"
+	default:
+		return fmt.Sprintf("Unknown PixmanFilter: %d", uint32(f))
+	}
+}
+
+func (k PixmanKernel) String() string {
+	switch k {
+	case PIXMAN_KERNEL_IMPULSE:
+		return "PIXMAN_KERNEL_IMPULSE"
+	case PIXMAN_KERNEL_BOX:
+		return "PIXMAN_KERNEL_BOX"
+	case PIXMAN_KERNEL_LINEAR:
+		return "PIXMAN_KERNEL_LINEAR"
+	case PIXMAN_KERNEL_CUBIC:
+		return "PIXMAN_KERNEL_CUBIC"
+	case PIXMAN_KERNEL_GAUSSIAN:
+		return "PIXMAN_KERNEL_GAUSSIAN"
+	case PIXMAN_KERNEL_LANCZOS2:
+		return "PIXMAN_KERNEL_LANCZOS2"
+	case PIXMAN_KERNEL_LANCZOS3:
+		return "PIXMAN_KERNEL_LANCZOS3"
+	case PIXMAN_KERNEL_LANCZOS3_STRETCHED:
+		return "PIXMAN_KERNEL_LANCZOS3_STRETCHED"
+	default:
+		return fmt.Sprintf("Unknown PixmanKernel: %d", uint32(k))
+	}
+}
+
+func (r PixmanRepeat) String() string {
+	switch r {
+	case PIXMAN_REPEAT_NONE:
+		return "PIXMAN_REPEAT_NONE"
+	case PIXMAN_REPEAT_NORMAL:
+		return "PIXMAN_REPEAT_NORMAL"
+	case PIXMAN_REPEAT_PAD:
+		return "PIXMAN_REPEAT_PAD"
+	case PIXMAN_REPEAT_REFLECT:
+		return "PIXMAN_REPEAT_REFLECT"
+	default:
+		return fmt.Sprintf("Unknown PixmanRepeat: %d", uint32(r))
+	}
+}
+
+func (o PixmanRegionOverlap) String() string {
+	switch o {
+	case PIXMAN_REGION_OUT:
+		return "PIXMAN_REGION_OUT"
+	case PIXMAN_REGION_IN:
+		return "PIXMAN_REGION_IN"
+	case PIXMAN_REGION_PART:
+		return "PIXMAN_REGION_PART"
+	default:
+		return fmt.Sprintf("Unknown PixmanRegionOverlap: %d", uint32(o))
+	}
+}